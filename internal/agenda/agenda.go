@@ -0,0 +1,141 @@
+package agenda
+
+import (
+	"fmt"
+	"midweek-project/internal/parser"
+	"strings"
+	"time"
+)
+
+// Tempos fixos da reunião, em minutos, além das partes da apostila.
+const (
+	MaxMinutes = 105
+
+	openingSongMinutes        = 5
+	openingCommentsMinutes    = 1
+	midSongMinutes            = 3
+	concludingCommentsMinutes = 3
+	closingSongMinutes        = 5
+	counselMinutes            = 1
+)
+
+// DefaultStart é o horário de início usado quando nenhum é informado.
+const DefaultStart = 19*time.Hour + 30*time.Minute
+
+type ItemKind string
+
+const (
+	ItemOpeningSong        ItemKind = "opening_song"
+	ItemOpeningComments    ItemKind = "opening_comments"
+	ItemPart               ItemKind = "part"
+	ItemMidSong            ItemKind = "mid_song"
+	ItemConcludingComments ItemKind = "concluding_comments"
+	ItemClosingSong        ItemKind = "closing_song"
+)
+
+// Item é um trecho da reunião. Start e End são horários contados a partir da
+// meia-noite.
+type Item struct {
+	Kind  ItemKind
+	Part  int
+	Start time.Duration
+	End   time.Duration
+}
+
+type Agenda struct {
+	Start time.Duration
+	End   time.Duration
+	Items []Item
+	// Overrun é quanto a reunião passa de MaxMinutes; zero quando cabe.
+	Overrun time.Duration
+}
+
+// Build monta o horário corrido da semana: cântico e oração, comentários
+// iniciais, as partes com o conselho depois das partes de estudante, o cântico
+// do meio antes de Nossa Vida Cristã, os comentários finais e o cântico final.
+func Build(m parser.MeetingData, start time.Duration) Agenda {
+	a := Agenda{Start: start}
+	clock := start
+	add := func(kind ItemKind, part, minutes int) {
+		end := clock + time.Duration(minutes)*time.Minute
+		a.Items = append(a.Items, Item{Kind: kind, Part: part, Start: clock, End: end})
+		clock = end
+	}
+
+	add(ItemOpeningSong, 0, openingSongMinutes)
+	add(ItemOpeningComments, 0, openingCommentsMinutes)
+	for _, section := range parser.Sections {
+		if section == parser.SectionChristian {
+			add(ItemMidSong, 0, midSongMinutes)
+		}
+		for _, part := range m.PartsIn(section) {
+			add(ItemPart, part.Number, part.Minutes)
+			if receivesCounsel(part) {
+				clock += counselMinutes * time.Minute
+			}
+		}
+	}
+	add(ItemConcludingComments, 0, concludingCommentsMinutes)
+	add(ItemClosingSong, 0, closingSongMinutes)
+
+	a.End = clock
+	if over := a.End - a.Start - MaxMinutes*time.Minute; over > 0 {
+		a.Overrun = over
+	}
+	return a
+}
+
+// Find devolve o primeiro trecho do tipo informado.
+func (a Agenda) Find(kind ItemKind) (Item, bool) {
+	for _, item := range a.Items {
+		if item.Kind == kind {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+// Part devolve o trecho da parte com o número informado.
+func (a Agenda) Part(number int) (Item, bool) {
+	for _, item := range a.Items {
+		if item.Kind == ItemPart && item.Part == number {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+// receivesCounsel indica as partes de estudante, que recebem conselho do
+// presidente logo depois.
+func receivesCounsel(part parser.Part) bool {
+	return part.Kind == parser.KindBibleReading || part.Section == parser.SectionMinistry
+}
+
+// ParseClock lê um horário no formato "19:30".
+func ParseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseWeekday lê o dia da semana em inglês, como "tuesday".
+func ParseWeekday(value string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(strings.TrimSpace(value), day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q, expected a name such as tuesday", value)
+}
+
+func FormatClock(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	return fmt.Sprintf("%02d:%02d", minutes/60%24, minutes%60)
+}
+
+// FormatRange formata o trecho como "19:36–19:46".
+func (i Item) FormatRange() string {
+	return FormatClock(i.Start) + "–" + FormatClock(i.End)
+}
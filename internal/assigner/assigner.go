@@ -4,34 +4,169 @@ import (
 	"fmt"
 	"github.com/xuri/excelize/v2"
 	"math/rand"
+	"midweek-project/internal/store"
 	"sort"
 	"strings"
 	"time"
 )
 
 const (
-	sheetName = "Sheet1"
+	sheetName  = "Sheet1"
+	dateLayout = "02/01/2006"
 )
 
 type Designated struct {
 	Name            string
 	LastDesignation string
+	// Function é a coluna da planilha de onde o designado entrou na fila; numa
+	// fila combinada é nela que a designação é registrada.
+	Function string
+	store.Attributes
 }
 
-func LoadAvailableDesignatesFromFile(f *excelize.File) (map[string][]Designated, error) {
+type sheetPublisher struct {
+	Name string
+	// LastDesignations mapeia cada função em que o publicador está apto para a
+	// data da última designação registrada na planilha (pode ser vazia).
+	LastDesignations map[string]string
+}
+
+// ImportDesignatesFromFile grava no repositório os publicadores da planilha,
+// com as funções em que estão aptos e os atributos da aba "Cadastro", e importa
+// como histórico as datas de última designação que ainda não constam no
+// repositório. A planilha decide quem está ativo: quem não aparece nela fica
+// inativo até voltar a ser listado.
+func ImportDesignatesFromFile(f *excelize.File, repo store.Repository) error {
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		return nil, fmt.Errorf("error reading tab %s: %v", sheetName, err)
+		return fmt.Errorf("error reading tab %s: %v", sheetName, err)
 	}
 	if len(rows) < 2 {
-		return nil, fmt.Errorf("sheet without enough data")
+		return fmt.Errorf("sheet without enough data")
 	}
 
 	headers := rows[0]
-	designates := parseDesignatedRows(f, rows[1:], headers)
+	sheetPublishers, err := parseDesignatedRows(f, rows[1:], headers)
+	if err != nil {
+		return err
+	}
+
+	history, err := repo.Assignments()
+	if err != nil {
+		return err
+	}
+	latest := latestByFunction(history, "")
+
+	stored, err := repo.Publishers()
+	if err != nil {
+		return err
+	}
+	profiles, found, err := readProfiles(f)
+	if err != nil {
+		return err
+	}
+	if !found {
+		// Sem a aba "Cadastro", os atributos já importados continuam valendo.
+		profiles = make(map[string]store.Attributes, len(stored))
+		for _, p := range stored {
+			profiles[p.Name] = p.Attributes
+		}
+	}
+
+	var publishers []store.Publisher
+	var imported []store.Assignment
+	for _, sp := range sheetPublishers {
+		publisher := store.Publisher{Name: sp.Name, Attributes: profiles[sp.Name]}
+		for function, lastDesignation := range sp.LastDesignations {
+			publisher.Functions = append(publisher.Functions, function)
+
+			date, err := time.Parse(dateLayout, lastDesignation)
+			if err != nil {
+				continue
+			}
+			if last, ok := latest[sp.Name][function]; ok && !last.Before(date) {
+				continue
+			}
+			imported = append(imported, store.Assignment{
+				Date:     date,
+				Function: function,
+				Role:     roleForFunction(function),
+				Name:     sp.Name,
+			})
+		}
+		sort.Strings(publisher.Functions)
+		publishers = append(publishers, publisher)
+	}
+
+	listed := make(map[string]bool, len(publishers))
+	for _, p := range publishers {
+		listed[p.Name] = true
+	}
+	for _, p := range stored {
+		if !listed[p.Name] && !p.Inactive {
+			p.Inactive = true
+			publishers = append(publishers, p)
+		}
+	}
+
+	if err := repo.SavePublishers(publishers); err != nil {
+		return err
+	}
+	if err := repo.AddAssignments(imported); err != nil {
+		return err
+	}
+	if err := importUnavailability(f, repo); err != nil {
+		return err
+	}
+	return importPairings(f, repo)
+}
+
+// LoadAvailableDesignates monta, a partir do repositório, a fila de cada função
+// ordenada pela última designação, só com os publicadores ativos. O histórico
+// do próprio período é ignorado, já que ele será regerado.
+func LoadAvailableDesignates(repo store.Repository, period string, rng *rand.Rand) (map[string][]Designated, error) {
+	publishers, err := repo.Publishers()
+	if err != nil {
+		return nil, err
+	}
+	history, err := repo.Assignments()
+	if err != nil {
+		return nil, err
+	}
+	latest := latestByFunction(history, period)
+
+	designates := make(map[string][]Designated)
+	for _, p := range publishers {
+		if p.Inactive {
+			continue
+		}
+		attributes := p.Attributes
+		if attributes.Gender == "" {
+			attributes.Gender = inferGender(p.Functions)
+		}
+		for _, function := range p.Functions {
+			designated := Designated{Name: p.Name, Function: function, Attributes: attributes}
+			if last, ok := latest[p.Name][function]; ok {
+				designated.LastDesignation = last.Format(dateLayout)
+			}
+			designates[function] = append(designates[function], designated)
+		}
+	}
+	if len(designates) == 0 {
+		return nil, fmt.Errorf("no designates available")
+	}
+
+	// Funções em ordem fixa para que o embaralhamento consuma o gerador sempre
+	// na mesma sequência.
+	functions := make([]string, 0, len(designates))
+	for function := range designates {
+		functions = append(functions, function)
+	}
+	sort.Strings(functions)
 
-	for function, list := range designates {
-		shuffleDesignated(list)
+	for _, function := range functions {
+		list := designates[function]
+		shuffleDesignated(list, rng)
 		sort.SliceStable(list, func(i, j int) bool {
 			return compareByDatePriority(list[i], list[j])
 		})
@@ -41,8 +176,31 @@ func LoadAvailableDesignatesFromFile(f *excelize.File) (map[string][]Designated,
 	return designates, nil
 }
 
-func parseDesignatedRows(f *excelize.File, dataRows [][]string, headers []string) map[string][]Designated {
-	result := make(map[string][]Designated)
+func latestByFunction(history []store.Assignment, excludePeriod string) map[string]map[string]time.Time {
+	latest := make(map[string]map[string]time.Time)
+	for _, a := range history {
+		if excludePeriod != "" && a.Period == excludePeriod {
+			continue
+		}
+		if latest[a.Name] == nil {
+			latest[a.Name] = make(map[string]time.Time)
+		}
+		if a.Date.After(latest[a.Name][a.Function]) {
+			latest[a.Name][a.Function] = a.Date
+		}
+	}
+	return latest
+}
+
+func roleForFunction(function string) string {
+	if strings.HasPrefix(function, "Ajudante") || function == FUNC_LEITOR_ESTUDO {
+		return store.RoleAssistant
+	}
+	return store.RoleHolder
+}
+
+func parseDesignatedRows(f *excelize.File, dataRows [][]string, headers []string) ([]sheetPublisher, error) {
+	var result []sheetPublisher
 
 	publicadorIdx := -1
 	firstFunctionIdx := -1
@@ -55,10 +213,10 @@ func parseDesignatedRows(f *excelize.File, dataRows [][]string, headers []string
 		}
 	}
 	if publicadorIdx == -1 {
-		panic("'Publishers' column not found")
+		return nil, fmt.Errorf("tab %s has no 'Publicadores' column", sheetName)
 	}
 	if firstFunctionIdx == -1 {
-		panic("No functions found after 'Publishers'")
+		return nil, fmt.Errorf("tab %s has no function columns after 'Publicadores'", sheetName)
 	}
 
 	for rowIdx, row := range dataRows {
@@ -68,9 +226,13 @@ func parseDesignatedRows(f *excelize.File, dataRows [][]string, headers []string
 		if publicadorIdx >= len(row) {
 			continue
 		}
-		name := row[publicadorIdx]
+		name := strings.TrimSpace(row[publicadorIdx])
+		if name == "" {
+			continue
+		}
+		publisher := sheetPublisher{Name: name, LastDesignations: make(map[string]string)}
 
-		for colIdx := firstFunctionIdx; colIdx < len(headers)-1; colIdx += 2 {
+		for colIdx := firstFunctionIdx; colIdx < len(headers); colIdx += 2 {
 			function := strings.TrimSpace(headers[colIdx])
 			aptCell := cellPosition(colIdx+1, rowIdx+2)
 			dateCell := cellPosition(colIdx+2, rowIdx+2)
@@ -79,15 +241,44 @@ func parseDesignatedRows(f *excelize.File, dataRows [][]string, headers []string
 			dateVal, _ := f.GetCellValue(sheetName, dateCell)
 
 			if isDesignated(aptVal) {
-				designated := Designated{
-					Name:            name,
-					LastDesignation: strings.TrimSpace(dateVal),
-				}
-				result[function] = append(result[function], designated)
+				publisher.LastDesignations[function] = strings.TrimSpace(dateVal)
 			}
 		}
+		result = append(result, publisher)
+	}
+	return result, nil
+}
+
+// sheetFunctions devolve as funções que têm coluna na planilha de designados.
+func sheetFunctions(f *excelize.File) (map[string]bool, error) {
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tab %s: %v", sheetName, err)
+	}
+	functions := make(map[string]bool)
+	if len(rows) == 0 {
+		return functions, nil
+	}
+
+	headers := rows[0]
+	publicadorIdx := -1
+	for idx, header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header), "Publicadores") {
+			publicadorIdx = idx
+			break
+		}
+	}
+	first := -1
+	for idx := publicadorIdx + 1; publicadorIdx != -1 && idx < len(headers); idx++ {
+		if strings.TrimSpace(headers[idx]) != "" {
+			first = idx
+			break
+		}
+	}
+	for idx := first; first != -1 && idx < len(headers); idx += 2 {
+		functions[strings.TrimSpace(headers[idx])] = true
 	}
-	return result
+	return functions, nil
 }
 
 func compareByDatePriority(a, b Designated) bool {
@@ -101,8 +292,8 @@ func compareByDatePriority(a, b Designated) bool {
 		return false
 	}
 
-	dateA, errA := time.Parse("02/01/2006", a.LastDesignation)
-	dateB, errB := time.Parse("02/01/2006", b.LastDesignation)
+	dateA, errA := time.Parse(dateLayout, a.LastDesignation)
+	dateB, errB := time.Parse(dateLayout, b.LastDesignation)
 
 	if errA != nil && errB == nil {
 		return false
@@ -117,9 +308,8 @@ func compareByDatePriority(a, b Designated) bool {
 	return dateA.Before(dateB)
 }
 
-func shuffleDesignated(list []Designated) {
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(list), func(i, j int) {
+func shuffleDesignated(list []Designated, rng *rand.Rand) {
+	rng.Shuffle(len(list), func(i, j int) {
 		list[i], list[j] = list[j], list[i]
 	})
 }
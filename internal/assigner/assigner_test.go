@@ -0,0 +1,123 @@
+package assigner
+
+import (
+	"fmt"
+	"math/rand"
+	"midweek-project/internal/locale"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const testPeriod = "2025-03"
+
+// testFunctions são as colunas da planilha de designados usadas nos testes,
+// com duas salas.
+var testFunctions = []string{
+	FUNC_PRESIDENTE, FUNC_CONSELHEIRO, FUNC_ORACAO,
+	FUNC_LEITOR_BIBLIA_A, FUNC_LEITOR_BIBLIA_B,
+	FUNC_DISCURSO_TESOUROS, FUNC_JOIAS, FUNC_DISCURSO_MINISTERIO,
+	FUNC_DISCURSO_CRISTA, FUNC_ESTUDO_BIBLICO, FUNC_LEITOR_ESTUDO,
+	FUNC_TITULAR_A_HOMEM, FUNC_AJUDANTE_A_HOMEM,
+	FUNC_TITULAR_A_MULHER, FUNC_AJUDANTE_A_MULHER,
+	FUNC_TITULAR_B_HOMEM, FUNC_AJUDANTE_B_HOMEM,
+	FUNC_TITULAR_B_MULHER, FUNC_AJUDANTE_B_MULHER,
+}
+
+// testMeetings monta as semanas de março de 2025 a partir de 3 de março.
+func testMeetings(t *testing.T, weeks int) []parser.MeetingData {
+	t.Helper()
+	contents := make([]string, weeks)
+	for i := range contents {
+		start := 3 + 7*i
+		contents[i] = fmt.Sprintf(`%d a %d de março
+ISAÍAS 1-2
+Cântico 12 e oração | Comentários iniciais (1 min)
+TESOUROS DA PALAVRA DE DEUS
+1. Algo importante (10 min)
+2. Joias espirituais (10 min)
+3. Leitura da Bíblia (4 min) Is 1:1-10 (th lição 10)
+FAÇA SEU MELHOR NO MINISTÉRIO
+4. Iniciando conversas (3 min) DE CASA EM CASA. (lmd lição 1 ponto 3)
+5. Discurso (5 min) (th lição 7)
+NOSSA VIDA CRISTÃ
+Cântico 50
+6. Necessidades locais (15 min)
+7. Estudo bíblico de congregação (30 min)
+Comentários finais (3 min) | Cântico 100 e oração
+`, start, start+6)
+	}
+	meetings, err := parser.ParseAllMeetings(contents, parser.DateReference{Year: 2025, Month: 3}, locale.MustGet(locale.Default))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return meetings
+}
+
+// testSheet monta a planilha de designados com os publicadores dados, cada um
+// apto nas funções em que apt devolve verdadeiro.
+func testSheet(t *testing.T, names []string, apt func(row, function int) bool) *excelize.File {
+	t.Helper()
+	f := excelize.NewFile()
+	f.SetCellValue(sheetName, "A1", "Publicadores")
+	for i, function := range testFunctions {
+		column, _ := excelize.ColumnNumberToName(2 + 2*i)
+		f.SetCellValue(sheetName, column+"1", function)
+		last, _ := excelize.ColumnNumberToName(3 + 2*i)
+		f.SetCellValue(sheetName, last+"1", "Última")
+	}
+	for row, name := range names {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row+2), name)
+		for i := range testFunctions {
+			if apt(row, i) {
+				column, _ := excelize.ColumnNumberToName(2 + 2*i)
+				f.SetCellValue(sheetName, fmt.Sprintf("%s%d", column, row+2), "1")
+			}
+		}
+	}
+	return f
+}
+
+// testNames devolve n nomes "PubNN".
+func testNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("Pub%02d", i)
+	}
+	return names
+}
+
+func newRepository(t *testing.T) store.Repository {
+	t.Helper()
+	return store.NewJSONRepository(filepath.Join(t.TempDir(), "store.json"))
+}
+
+// testRepository importa f num repositório novo e devolve o pool do período.
+func testRepository(t *testing.T, f *excelize.File, seed int64) (store.Repository, map[string][]Designated) {
+	t.Helper()
+	repo := newRepository(t)
+	if err := ImportDesignatesFromFile(f, repo); err != nil {
+		t.Fatal(err)
+	}
+	pool, err := LoadAvailableDesignates(repo, testPeriod, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo, pool
+}
+
+// generate importa f e gera weeks semanas com a semente dada.
+func generate(t *testing.T, f *excelize.File, weeks int, seed int64, opts Options) *Result {
+	t.Helper()
+	repo, pool := testRepository(t, f, seed)
+	opts.Weights = DefaultWeights
+	opts.Rand = rand.New(rand.NewSource(seed))
+	result, err := AssignToMeetings(testMeetings(t, weeks), pool, repo, f, testPeriod, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
@@ -0,0 +1,143 @@
+package assigner
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"midweek-project/internal/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const unavailabilitySheet = "Indisponibilidade"
+
+// sheetDateLayouts são os formatos de data aceitos nas abas da planilha, com o
+// dia antes do mês. A exceção é "01-02-06", que é como o excelize devolve uma
+// célula de data com o formato padrão do Excel (mm-dd-yy): "03-10-25" é 10 de
+// março. Com hífen, uma data digitada com o dia primeiro precisa do ano com
+// quatro dígitos.
+var sheetDateLayouts = []string{"02/01/2006", "2/1/2006", "2006-01-02", "02-01-2006", "01-02-06", "02/01/06"}
+
+// availability indexa os intervalos de indisponibilidade por publicador.
+type availability map[string][]store.Unavailability
+
+func newAvailability(entries []store.Unavailability) availability {
+	a := make(availability)
+	for _, u := range entries {
+		a[u.Name] = append(a[u.Name], u)
+	}
+	return a
+}
+
+// available indica se o publicador pode ser designado na semana da vaga: um
+// intervalo de indisponibilidade que toque qualquer dia da semana o exclui.
+func (a availability) available(name string, s slot) bool {
+	start, end := s.weekStart, s.weekEnd
+	if end.IsZero() {
+		end = s.date
+	}
+	if end.IsZero() {
+		return true
+	}
+	if start.IsZero() {
+		start = end.AddDate(0, 0, -6)
+	}
+	for _, u := range a[name] {
+		if !u.From.After(end) && !u.To.Before(start) {
+			return false
+		}
+	}
+	return true
+}
+
+// importUnavailability lê a aba opcional "Indisponibilidade" da planilha de
+// designados, com as colunas Publicador, Início, Fim e Motivo. Sem a aba, os
+// intervalos já importados continuam valendo.
+func importUnavailability(f *excelize.File, repo store.Repository) error {
+	if idx, err := f.GetSheetIndex(unavailabilitySheet); err != nil || idx == -1 {
+		return nil
+	}
+
+	rows, err := f.GetRows(unavailabilitySheet)
+	if err != nil {
+		return fmt.Errorf("error reading tab %s: %v", unavailabilitySheet, err)
+	}
+	if len(rows) == 0 {
+		return repo.ReplaceUnavailabilities(store.SourceSheet, nil)
+	}
+
+	nameIdx, fromIdx, toIdx, reasonIdx := -1, -1, -1, -1
+	for idx, header := range rows[0] {
+		switch normalizeHeader(header) {
+		case "publicador", "publicadores", "nome":
+			nameIdx = idx
+		case "inicio", "de":
+			fromIdx = idx
+		case "fim", "ate":
+			toIdx = idx
+		case "motivo":
+			reasonIdx = idx
+		}
+	}
+	if nameIdx == -1 || fromIdx == -1 {
+		return fmt.Errorf("tab %s must have 'Publicador' and 'Início' columns", unavailabilitySheet)
+	}
+
+	var entries []store.Unavailability
+	for rowIdx, row := range rows[1:] {
+		name := cellAt(row, nameIdx)
+		if name == "" {
+			continue
+		}
+		from, err := parseSheetDate(cellAt(row, fromIdx))
+		if err != nil {
+			return fmt.Errorf("tab %s, row %d: invalid start date: %w", unavailabilitySheet, rowIdx+2, err)
+		}
+		to := from
+		if value := cellAt(row, toIdx); value != "" {
+			if to, err = parseSheetDate(value); err != nil {
+				return fmt.Errorf("tab %s, row %d: invalid end date: %w", unavailabilitySheet, rowIdx+2, err)
+			}
+		}
+		if to.Before(from) {
+			return fmt.Errorf("tab %s, row %d: end date before start date", unavailabilitySheet, rowIdx+2)
+		}
+		entries = append(entries, store.Unavailability{
+			Name:   name,
+			From:   from,
+			To:     to,
+			Reason: cellAt(row, reasonIdx),
+		})
+	}
+
+	return repo.ReplaceUnavailabilities(store.SourceSheet, entries)
+}
+
+func parseSheetDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range sheetDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	// Células de data sem formatação chegam como número de série do Excel.
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		date, err := excelize.ExcelDateToTime(serial, false)
+		if err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+func normalizeHeader(header string) string {
+	h := strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer("í", "i", "é", "e", "ê", "e", "á", "a", "ã", "a", "ç", "c", "ó", "o").Replace(h)
+}
+
+func cellAt(row []string, idx int) string {
+	if idx < 0 || idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}
@@ -0,0 +1,59 @@
+package assigner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseSheetDate(t *testing.T) {
+	march10 := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"10/03/2025", march10},
+		{"10/3/2025", march10},
+		{"2025-03-10", march10},
+		{"10-03-2025", march10},
+		{"10/03/25", march10},
+		// Dois dígitos no ano e hífen: o formato mm-dd-yy das células de data.
+		{"03-10-25", march10},
+		{"45726", march10},
+	}
+	for _, tt := range tests {
+		got, err := parseSheetDate(tt.value)
+		if err != nil {
+			t.Errorf("parseSheetDate(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSheetDate(%q) = %s, want %s", tt.value, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+
+	if _, err := parseSheetDate("10 de março"); err == nil {
+		t.Error("parseSheetDate(\"10 de março\") succeeded, want an error")
+	}
+}
+
+// TestParseSheetDateCell lê uma célula de data como o excelize a devolve.
+func TestParseSheetDateCell(t *testing.T) {
+	march10 := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	f := excelize.NewFile()
+	if err := f.SetCellValue(sheetName, "A1", march10); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseSheetDate(rows[0][0])
+	if err != nil {
+		t.Fatalf("parseSheetDate(%q): %v", rows[0][0], err)
+	}
+	if !got.Equal(march10) {
+		t.Errorf("cell %q read as %s, want 2025-03-10", rows[0][0], got.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,201 @@
+package assigner
+
+import (
+	"fmt"
+	"midweek-project/internal/store"
+	"sort"
+	"strings"
+)
+
+const (
+	ConflictUnavailable       = "unavailable"
+	ConflictUnfilled          = "unfilled"
+	ConflictDoubleBooked      = "double_booked"
+	ConflictAssistantIsHolder = "assistant_is_holder"
+	ConflictMissingFunction   = "missing_function"
+	ConflictIneligible        = "ineligible"
+	ConflictNotListed         = "not_listed"
+)
+
+// Conflict aponta uma vaga em que alguma regra não pôde ser respeitada.
+type Conflict struct {
+	Kind     string `json:"kind"`
+	Week     string `json:"week,omitempty"`
+	Part     string `json:"part,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Hall     string `json:"hall,omitempty"`
+	Function string `json:"function,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ConflictError é devolvido no modo estrito quando a escala tem conflitos; nada
+// é gravado no histórico nesse caso.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	kinds := make(map[string]int)
+	for _, c := range e.Conflicts {
+		kinds[c.Kind]++
+	}
+	parts := make([]string, 0, len(kinds))
+	for kind, count := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", count, kind))
+	}
+	sort.Strings(parts)
+	return fmt.Sprintf("schedule has %d conflicts (%s)", len(e.Conflicts), strings.Join(parts, ", "))
+}
+
+// collectConflicts revisa a escala final. columns traz as funções que existem na
+// planilha de designados; as demais funções usadas pela programação são
+// relatadas uma vez cada (e acrescentadas a columns). Uma fila combinada só
+// falta se nenhuma das suas colunas existir.
+func collectConflicts(plan []slot, names []string, absences availability, people profiles, columns map[string]bool) []Conflict {
+	conflicts := make([]Conflict, 0)
+
+	for function, members := range functionGroups {
+		for _, member := range members {
+			if columns[member] {
+				columns[function] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, s := range plan {
+		if !columns[s.function] {
+			columns[s.function] = true
+			missing = append(missing, s.function)
+		}
+	}
+	sort.Strings(missing)
+	for _, function := range missing {
+		conflicts = append(conflicts, Conflict{
+			Kind:     ConflictMissingFunction,
+			Function: function,
+			Message:  fmt.Sprintf("the designates sheet has no column for %q", function),
+		})
+	}
+
+	type weekName struct {
+		week int
+		name string
+	}
+	booked := make(map[weekName]string)
+
+	for i, s := range plan {
+		name := names[i]
+		if name == "" {
+			conflicts = append(conflicts, Conflict{
+				Kind:     ConflictUnfilled,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Message:  fmt.Sprintf("nobody is available for %s", s.function),
+			})
+			continue
+		}
+
+		if !absences.available(name, s) {
+			conflicts = append(conflicts, Conflict{
+				Kind:     ConflictUnavailable,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Name:     name,
+				Message:  fmt.Sprintf("%s is unavailable this week but no other candidate was free", name),
+			})
+		}
+
+		if people.unlisted(name) {
+			conflicts = append(conflicts, Conflict{
+				Kind:     ConflictNotListed,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Name:     name,
+				Message:  fmt.Sprintf("%s is not in tab %s, so the rules for %s cannot be checked", name, profilesSheet, s.part),
+			})
+		} else if !people.eligible(s, name) {
+			conflicts = append(conflicts, Conflict{
+				Kind:     ConflictIneligible,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Name:     name,
+				Message:  fmt.Sprintf("%s is not eligible for %s (requires %s)", name, s.part, groupList(eligibility[s.rule])),
+			})
+		} else if !people.allows(plan, names, i, name) {
+			holder := names[s.distinctFrom]
+			conflicts = append(conflicts, Conflict{
+				Kind:     ConflictIneligible,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Name:     name,
+				Message:  fmt.Sprintf("%s cannot assist %s in part %s (%s)", name, holder, s.part, people.pairIssue(holder, name)),
+			})
+		}
+
+		if s.distinctFrom >= 0 && names[s.distinctFrom] == name {
+			kind := ConflictDoubleBooked
+			message := fmt.Sprintf("%s was assigned twice to linked parts %s and %s", name, plan[s.distinctFrom].part, s.part)
+			if s.role == store.RoleAssistant {
+				kind = ConflictAssistantIsHolder
+				message = fmt.Sprintf("%s is both holder and assistant in part %s", name, s.part)
+			}
+			conflicts = append(conflicts, Conflict{
+				Kind:     kind,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Name:     name,
+				Message:  message,
+			})
+			continue
+		}
+
+		if !s.exclusive {
+			continue
+		}
+		key := weekName{s.week, name}
+		if part, ok := booked[key]; ok {
+			conflicts = append(conflicts, Conflict{
+				Kind:     ConflictDoubleBooked,
+				Week:     s.meeting,
+				Part:     s.part,
+				Role:     s.role,
+				Hall:     s.hall,
+				Function: s.function,
+				Name:     name,
+				Message:  fmt.Sprintf("%s is already assigned to part %s this week", name, part),
+			})
+			continue
+		}
+		booked[key] = s.part
+	}
+
+	return conflicts
+}
+
+func groupList(groups []group) string {
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = string(g)
+	}
+	return strings.Join(parts, " or ")
+}
@@ -0,0 +1,172 @@
+package assigner
+
+import (
+	"github.com/xuri/excelize/v2"
+	"midweek-project/internal/store"
+	"time"
+)
+
+// frequencyWindow é o período considerado ao contar quantas vezes alguém já foi
+// designado.
+const frequencyWindow = 182 * 24 * time.Hour
+
+// history é o registro completo de designações por publicador: o que veio do
+// repositório mais o que foi designado na geração atual. As novas designações
+// são gravadas no repositório ao final e refletidas na planilha de designados.
+type history struct {
+	file      *excelize.File
+	period    string
+	weights   Weights
+	absences  availability
+	profiles  profiles
+	records   []store.Assignment
+	byName    map[string][]store.Assignment
+	decisions []Decision
+}
+
+func newHistory(repo store.Repository, f *excelize.File, period string) (*history, error) {
+	assignments, err := repo.Assignments()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &history{file: f, period: period, byName: make(map[string][]store.Assignment)}
+	for _, a := range assignments {
+		if a.Period == period {
+			continue
+		}
+		h.byName[a.Name] = append(h.byName[a.Name], a)
+	}
+	return h, nil
+}
+
+// record registra name na vaga. column é a coluna da planilha de onde a pessoa
+// saiu; vazia, vale a função da vaga.
+func (h *history) record(s slot, name, column string) {
+	if s.date.IsZero() {
+		return
+	}
+	if column == "" {
+		column = s.function
+	}
+	a := store.Assignment{
+		Period:   h.period,
+		Week:     s.meeting,
+		Date:     s.date,
+		Function: column,
+		Part:     s.part,
+		Role:     s.role,
+		Hall:     s.hall,
+		Name:     name,
+	}
+	h.records = append(h.records, a)
+	h.byName[name] = append(h.byName[name], a)
+}
+
+// reset descarta o que foi designado nesta geração, mantendo o histórico anterior.
+func (h *history) reset() {
+	for name, assignments := range h.byName {
+		kept := assignments[:0]
+		for _, a := range assignments {
+			if a.Period != h.period {
+				kept = append(kept, a)
+			}
+		}
+		h.byName[name] = kept
+	}
+	h.records = nil
+	h.decisions = nil
+}
+
+// flush atualiza as datas de última designação na planilha e grava o
+// histórico do período no repositório.
+func (h *history) flush(repo store.Repository) error {
+	for _, a := range h.records {
+		_ = updateDesignationDate(h.file, a.Function, a.Name, a.Date)
+		if a.Part == FUNC_ORACAO_FINAL {
+			// A planilha pode ter uma coluna própria para a oração final.
+			_ = updateDesignationDate(h.file, FUNC_ORACAO_FINAL, a.Name, a.Date)
+		}
+	}
+	return repo.ReplacePeriodAssignments(h.period, h.records)
+}
+
+// lastInFunction é a recência no papel da vaga: cada coluna de função da
+// planilha já distingue titular, ajudante e sala, e uma fila combinada conta
+// todas as suas colunas.
+func (h *history) lastInFunction(name, function string) time.Time {
+	var last time.Time
+	for _, a := range h.byName[name] {
+		if functionIncludes(function, a.Function) && a.Date.After(last) {
+			last = a.Date
+		}
+	}
+	return last
+}
+
+// lastInRole é a recência no tipo de vaga, titular ou ajudante, em qualquer
+// função.
+func (h *history) lastInRole(name, role string) time.Time {
+	var last time.Time
+	for _, a := range h.byName[name] {
+		if a.Role == role && a.Date.After(last) {
+			last = a.Date
+		}
+	}
+	return last
+}
+
+func (h *history) lastAny(name string) time.Time {
+	var last time.Time
+	for _, a := range h.byName[name] {
+		if a.Date.After(last) {
+			last = a.Date
+		}
+	}
+	return last
+}
+
+// lastBeforePeriod é a última designação de cada pessoa antes desta geração.
+func (h *history) lastBeforePeriod() map[string]time.Time {
+	last := make(map[string]time.Time)
+	for name, assignments := range h.byName {
+		for _, a := range assignments {
+			if a.Period != h.period && a.Date.After(last[name]) {
+				last[name] = a.Date
+			}
+		}
+	}
+	return last
+}
+
+// countSince conta as designações de name desde since, do histórico e desta
+// geração.
+func (h *history) countSince(name string, since time.Time) int {
+	count := 0
+	for _, a := range h.byName[name] {
+		if !a.Date.Before(since) {
+			count++
+		}
+	}
+	return count
+}
+
+func (h *history) countInPeriod(name string) int {
+	count := 0
+	for _, a := range h.records {
+		if a.Name == name {
+			count++
+		}
+	}
+	return count
+}
+
+func (h *history) countInWeek(name, meeting string) int {
+	count := 0
+	for _, a := range h.records {
+		if a.Name == name && a.Week == meeting {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,152 @@
+package assigner
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"midweek-project/internal/store"
+	"time"
+)
+
+const pairingsSheet = "Pares"
+
+// recentPairDays é a janela em que repetir um par de demonstração é
+// desfavorecido; cobre o mês anterior inteiro.
+const recentPairDays = 62
+
+// pair é um par de publicadores sem ordem.
+type pair [2]string
+
+func newPair(a, b string) pair {
+	if b < a {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// lastPaired é a data mais recente em que assistant ajudou holder, ou em que
+// os dois fizeram par na ordem inversa.
+func (h *history) lastPaired(holder, assistant string) time.Time {
+	var last time.Time
+	for _, names := range [][2]string{{holder, assistant}, {assistant, holder}} {
+		for _, a := range h.byName[names[1]] {
+			if a.Role != store.RoleAssistant || a.Part == "" || !a.Date.After(last) {
+				continue
+			}
+			for _, b := range h.byName[names[0]] {
+				if b.Role == store.RoleHolder && b.Period == a.Period && b.Week == a.Week && b.Part == a.Part {
+					last = a.Date
+					break
+				}
+			}
+		}
+	}
+	return last
+}
+
+// pairsBeforePeriod é a data mais recente de cada par de demonstração antes
+// desta geração.
+func (h *history) pairsBeforePeriod() map[pair]time.Time {
+	type partKey struct{ period, week, part string }
+	holders := make(map[partKey]string)
+	for name, assignments := range h.byName {
+		for _, a := range assignments {
+			if a.Period != h.period && a.Role == store.RoleHolder && a.Part != "" {
+				holders[partKey{a.Period, a.Week, a.Part}] = name
+			}
+		}
+	}
+
+	last := make(map[pair]time.Time)
+	for name, assignments := range h.byName {
+		for _, a := range assignments {
+			if a.Period == h.period || a.Role != store.RoleAssistant {
+				continue
+			}
+			holder, ok := holders[partKey{a.Period, a.Week, a.Part}]
+			if !ok {
+				continue
+			}
+			p := newPair(holder, name)
+			if a.Date.After(last[p]) {
+				last[p] = a.Date
+			}
+		}
+	}
+	return last
+}
+
+// recentPair diz se os dois fizeram par nos recentPairDays antes da vaga.
+func (h *history) recentPair(holder, assistant string, s slot) bool {
+	last := h.lastPaired(holder, assistant)
+	return !last.IsZero() && daysSince(last, s.date) < recentPairDays
+}
+
+// partnerOf devolve o titular já escolhido quando a vaga idx é de ajudante de
+// demonstração.
+func partnerOf(plan []slot, names []string, idx int) string {
+	s := plan[idx]
+	if s.rule != ruleAssistant || s.distinctFrom < 0 {
+		return ""
+	}
+	return names[s.distinctFrom]
+}
+
+// importPairings lê a aba opcional "Pares" da planilha de designados, com as
+// colunas Publicador, Par e Tipo (preferido ou proibido). Sem a aba, as
+// preferências já importadas continuam valendo.
+func importPairings(f *excelize.File, repo store.Repository) error {
+	if idx, err := f.GetSheetIndex(pairingsSheet); err != nil || idx == -1 {
+		return nil
+	}
+
+	rows, err := f.GetRows(pairingsSheet)
+	if err != nil {
+		return fmt.Errorf("error reading tab %s: %v", pairingsSheet, err)
+	}
+	if len(rows) == 0 {
+		return repo.ReplacePairings(nil)
+	}
+
+	firstIdx, secondIdx, kindIdx := -1, -1, -1
+	for idx, header := range rows[0] {
+		switch normalizeHeader(header) {
+		case "publicador", "titular", "nome":
+			firstIdx = idx
+		case "par", "ajudante":
+			secondIdx = idx
+		case "tipo":
+			kindIdx = idx
+		}
+	}
+	if firstIdx == -1 || secondIdx == -1 || kindIdx == -1 {
+		return fmt.Errorf("tab %s must have 'Publicador', 'Par' and 'Tipo' columns", pairingsSheet)
+	}
+
+	var pairings []store.Pairing
+	for rowIdx, row := range rows[1:] {
+		first, second := cellAt(row, firstIdx), cellAt(row, secondIdx)
+		if first == "" && second == "" {
+			continue
+		}
+		if first == "" || second == "" || first == second {
+			return fmt.Errorf("tab %s, row %d: a pairing needs two different publishers", pairingsSheet, rowIdx+2)
+		}
+		kind, err := parsePairingKind(cellAt(row, kindIdx))
+		if err != nil {
+			return fmt.Errorf("tab %s, row %d: %w", pairingsSheet, rowIdx+2, err)
+		}
+		pairings = append(pairings, store.Pairing{First: first, Second: second, Kind: kind})
+	}
+
+	return repo.ReplacePairings(pairings)
+}
+
+func parsePairingKind(value string) (string, error) {
+	switch normalizeHeader(value) {
+	case "preferido", "preferida", "preferred":
+		return store.PairingPreferred, nil
+	case "proibido", "proibida", "evitar", "forbidden":
+		return store.PairingForbidden, nil
+	}
+	return "", fmt.Errorf("unknown pairing type %q", value)
+}
@@ -0,0 +1,153 @@
+package assigner
+
+import (
+	"midweek-project/internal/store"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestPairIssue(t *testing.T) {
+	p := profiles{
+		people: map[string]store.Attributes{
+			"Ana":   {Gender: store.GenderFemale, Household: "Silva"},
+			"Bia":   {Gender: store.GenderFemale},
+			"João":  {Gender: store.GenderMale, Household: "Silva"},
+			"Paulo": {Gender: store.GenderMale, Household: "Souza"},
+			"Rui":   {},
+		},
+		forbidden: map[pair]bool{newPair("Ana", "Bia"): true},
+	}
+
+	tests := []struct {
+		holder, assistant string
+		want              bool
+	}{
+		{"Ana", "Bia", false},
+		{"Bia", "Ana", false},
+		{"João", "Ana", true},
+		{"Ana", "João", true},
+		{"Paulo", "Ana", false},
+		{"Paulo", "João", true},
+		{"Rui", "Ana", true},
+	}
+	for _, tt := range tests {
+		if got := p.pairable(tt.holder, tt.assistant); got != tt.want {
+			t.Errorf("pairable(%s, %s) = %v, want %v", tt.holder, tt.assistant, got, tt.want)
+		}
+	}
+}
+
+// demonstrationSheet monta uma planilha em que só as pessoas de demonstrators
+// estão nas colunas de demonstração; as outras fazem o resto da reunião.
+func demonstrationSheet(t *testing.T, demonstrators map[string]bool, profiles [][]string) *excelize.File {
+	t.Helper()
+	names := testNames(30)
+	f := testSheet(t, names, func(row, function int) bool {
+		_, demonstration := functionGroupOf(testFunctions[function])
+		return demonstration == demonstrators[names[row]]
+	})
+	for _, name := range names {
+		if !demonstrators[name] {
+			profiles = append(profiles, []string{name, "M", "Sim", "Ancião", "Não", ""})
+		}
+	}
+	addProfiles(t, f, profiles)
+	return f
+}
+
+// functionGroupOf devolve a fila combinada da coluna, se houver.
+func functionGroupOf(column string) (string, bool) {
+	for function, members := range functionGroups {
+		for _, member := range members {
+			if member == column {
+				return function, true
+			}
+		}
+	}
+	return "", false
+}
+
+// demonstrationPairs devolve os pares titular e ajudante das partes feitas em
+// cada sala; o leitor do estudo, também ajudante, fica de fora.
+func demonstrationPairs(result *Result) []pair {
+	type partKey struct {
+		week int
+		part string
+	}
+	holders := make(map[partKey]string)
+	assistants := make(map[partKey]string)
+	for _, s := range result.Slots {
+		if s.Hall == "" {
+			continue
+		}
+		key := partKey{s.Week, s.Part}
+		switch s.Slot {
+		case store.RoleHolder:
+			holders[key] = s.Name
+		case store.RoleAssistant:
+			assistants[key] = s.Name
+		}
+	}
+	var pairs []pair
+	for key, assistant := range assistants {
+		if holder := holders[key]; holder != "" && assistant != "" {
+			pairs = append(pairs, newPair(holder, assistant))
+		}
+	}
+	return pairs
+}
+
+func TestForbiddenPairNeverProduced(t *testing.T) {
+	demonstrators := map[string]bool{"Pub00": true, "Pub01": true, "Pub02": true, "Pub03": true}
+	profiles := [][]string{
+		{"Pub00", "F", "Sim", "", "Não", ""},
+		{"Pub01", "F", "Sim", "", "Não", ""},
+		{"Pub02", "F", "Sim", "", "Não", ""},
+		{"Pub03", "F", "Sim", "", "Não", ""},
+	}
+	forbidden := newPair("Pub00", "Pub01")
+
+	for _, solver := range []bool{false, true} {
+		for seed := int64(1); seed <= 5; seed++ {
+			f := demonstrationSheet(t, demonstrators, profiles)
+			if _, err := f.NewSheet(pairingsSheet); err != nil {
+				t.Fatal(err)
+			}
+			f.SetSheetRow(pairingsSheet, "A1", &[]string{"Publicador", "Par", "Tipo"})
+			f.SetSheetRow(pairingsSheet, "A2", &[]string{"Pub00", "Pub01", "Proibido"})
+
+			result := generate(t, f, 4, seed, Options{Solver: solver, SolverIterations: 2000})
+			pairs := demonstrationPairs(result)
+			if len(pairs) == 0 {
+				t.Fatalf("solver=%v seed %d: no demonstration pairs", solver, seed)
+			}
+			for _, p := range pairs {
+				if p == forbidden {
+					t.Errorf("solver=%v seed %d: forbidden pair %v was produced", solver, seed, p)
+				}
+			}
+		}
+	}
+}
+
+func TestHouseholdPairAllowed(t *testing.T) {
+	// Só um casal faz demonstrações: o par misto é o único possível.
+	demonstrators := map[string]bool{"Pub00": true, "Pub01": true}
+	profiles := [][]string{
+		{"Pub00", "M", "Sim", "", "Não", "Silva"},
+		{"Pub01", "F", "Sim", "", "Não", "Silva"},
+	}
+	f := demonstrationSheet(t, demonstrators, profiles)
+
+	result := generate(t, f, 1, 1, Options{Classrooms: 1})
+	pairs := demonstrationPairs(result)
+	if len(pairs) != 1 || pairs[0] != newPair("Pub00", "Pub01") {
+		t.Fatalf("pairs %v, want the couple", pairs)
+	}
+	for _, c := range result.Conflicts {
+		if c.Kind == ConflictIneligible {
+			t.Errorf("unexpected conflict %+v", c)
+		}
+	}
+}
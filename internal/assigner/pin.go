@@ -0,0 +1,126 @@
+package assigner
+
+import (
+	"errors"
+	"fmt"
+	"midweek-project/internal/store"
+)
+
+var ErrInvalidPin = errors.New("invalid pin")
+
+// Pin fixa uma pessoa numa vaga antes da geração. Week é a posição da semana no
+// período, a partir de 1; Part é a chave da parte no mapa Designated (com a
+// sala nas partes de estudante, como "4.B") e Slot é holder ou assistant.
+type Pin struct {
+	Week int    `json:"week"`
+	Part string `json:"part"`
+	Slot string `json:"slot,omitempty"`
+	Name string `json:"name"`
+}
+
+// findSlot devolve o índice da vaga do pin, ou -1.
+func findSlot(plan []slot, pin Pin) int {
+	role := pin.Slot
+	if role == "" {
+		role = store.RoleHolder
+	}
+	for i, s := range plan {
+		if s.week == pin.Week-1 && s.part == pin.Part && s.role == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyPins marca as vagas fixadas e coloca os nomes em names. Um pin que não
+// corresponde a nenhuma vaga devolve ErrInvalidPin.
+func applyPins(plan []slot, names []string, pins []Pin) error {
+	for i, pin := range pins {
+		idx := findSlot(plan, pin)
+		if idx == -1 {
+			role := pin.Slot
+			if role == "" {
+				role = store.RoleHolder
+			}
+			return fmt.Errorf("%w %d: week %d has no %s slot for part %q", ErrInvalidPin, i+1, pin.Week, role, pin.Part)
+		}
+		if plan[idx].pinned && names[idx] != pin.Name {
+			return fmt.Errorf("%w %d: part %q of week %d is pinned twice", ErrInvalidPin, i+1, pin.Part, pin.Week)
+		}
+		plan[idx].pinned = true
+		names[idx] = pin.Name
+	}
+	return nil
+}
+
+// pin registra a vaga fixada idx como se tivesse sido escolhida, para que
+// ela conte no histórico e na pontuação das vagas seguintes. Uma vaga fixada
+// sem nome fica vazia.
+func (h *history) pin(plan []slot, names []string, idx int, pool map[string][]Designated) {
+	s := plan[idx]
+	name := names[idx]
+	decision := Decision{
+		Week:       s.meeting,
+		Part:       s.part,
+		Function:   s.function,
+		Role:       s.role,
+		Hall:       s.hall,
+		Chosen:     name,
+		Pinned:     true,
+		Candidates: []Score{},
+	}
+	if name != "" {
+		decision.Candidates = append(decision.Candidates, h.weights.score(h, name, s, partnerOf(plan, names, idx)))
+		h.record(s, name, columnFor(pool[s.function], name))
+	}
+	h.decisions = append(h.decisions, decision)
+}
+
+// pinnedExclusions são os nomes que a vaga idx não pode receber por causa de
+// vagas fixadas mais adiante: a mesma pessoa na semana ou no par.
+func pinnedExclusions(plan []slot, names []string, idx int) map[string]bool {
+	s := plan[idx]
+	excluded := make(map[string]bool)
+	for j := idx + 1; j < len(plan) && plan[j].week == s.week; j++ {
+		if !plan[j].pinned {
+			continue
+		}
+		if plan[j].distinctFrom == idx || (s.exclusive && plan[j].exclusive) {
+			excluded[names[j]] = true
+		}
+	}
+	return excluded
+}
+
+// pinnedPartnerAllows diz se name pode ser o titular da vaga idx quando o
+// ajudante dela foi fixado.
+func (p profiles) pinnedPartnerAllows(plan []slot, names []string, idx int, name string) bool {
+	for j := idx + 1; j < len(plan) && plan[j].week == plan[idx].week; j++ {
+		if plan[j].pinned && plan[j].rule == ruleAssistant && plan[j].distinctFrom == idx {
+			return p.pairable(name, names[j])
+		}
+	}
+	return true
+}
+
+// pinConflicts aponta vagas fixadas (por pin ou edição manual) com pessoas que
+// não estão aptas na função da vaga na planilha de designados.
+func pinConflicts(plan []slot, names []string, pool map[string][]Designated) []Conflict {
+	var conflicts []Conflict
+	for i, s := range plan {
+		if !s.pinned || names[i] == "" || columnFor(pool[s.function], names[i]) != "" {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			Kind:     ConflictNotListed,
+			Week:     s.meeting,
+			Part:     s.part,
+			Role:     s.role,
+			Hall:     s.hall,
+			Function: s.function,
+			Name:     names[i],
+			Message:  fmt.Sprintf("%s is assigned to %s but is not listed for %s", names[i], s.part, s.function),
+		})
+	}
+	return conflicts
+}
@@ -0,0 +1,93 @@
+package assigner
+
+import (
+	"errors"
+	"math/rand"
+	"midweek-project/internal/store"
+	"testing"
+)
+
+func TestPinKeptAndCounted(t *testing.T) {
+	f := testSheet(t, testNames(30), func(row, function int) bool { return (row+function)%3 != 0 })
+	repo, pool := testRepository(t, f, 1)
+	meetings := testMeetings(t, 2)
+	pin := Pin{Week: 1, Part: FUNC_PRESIDENTE, Name: "Pub01"}
+	opts := Options{Weights: DefaultWeights, Rand: rand.New(rand.NewSource(1)), Pins: []Pin{pin}}
+	result, err := AssignToMeetings(meetings, pool, repo, f, testPeriod, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exclusive := make(map[string]bool)
+	for _, s := range planMeetings(meetings, Halls(0)) {
+		if s.week == 0 && s.exclusive {
+			exclusive[s.part+"/"+s.role] = true
+		}
+	}
+	kept := false
+	for _, s := range result.Slots {
+		if s.Week != 1 || s.Name != "Pub01" {
+			continue
+		}
+		if s.Part == FUNC_PRESIDENTE {
+			kept = true
+			continue
+		}
+		role := s.Slot
+		if role == "" {
+			role = store.RoleHolder
+		}
+		if exclusive[s.Part+"/"+role] {
+			t.Errorf("pinned Pub01 also got %s in week 1", s.Part)
+		}
+	}
+	if !kept {
+		t.Fatal("Pub01 is not the chairman of week 1")
+	}
+
+	history, err := repo.Assignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counted := false
+	for _, a := range history {
+		if a.Period == testPeriod && a.Part == FUNC_PRESIDENTE && a.Name == "Pub01" && a.Week == meetings[0].MeetingDate {
+			counted = true
+		}
+	}
+	if !counted {
+		t.Error("the pinned chairman is not in the history")
+	}
+}
+
+func TestInvalidPin(t *testing.T) {
+	tests := []struct {
+		name string
+		pin  Pin
+	}{
+		{"unknown part", Pin{Week: 1, Part: "99", Name: "Pub01"}},
+		{"unknown week", Pin{Week: 3, Part: FUNC_PRESIDENTE, Name: "Pub01"}},
+		{"no assistant slot", Pin{Week: 1, Part: FUNC_PRESIDENTE, Slot: store.RoleAssistant, Name: "Pub01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testSheet(t, testNames(30), func(row, function int) bool { return true })
+			repo, pool := testRepository(t, f, 1)
+			opts := Options{Weights: DefaultWeights, Pins: []Pin{tt.pin}}
+			_, err := AssignToMeetings(testMeetings(t, 2), pool, repo, f, testPeriod, opts)
+			if !errors.Is(err, ErrInvalidPin) {
+				t.Fatalf("got %v, want ErrInvalidPin", err)
+			}
+
+			history, err := repo.Assignments()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, a := range history {
+				if a.Period == testPeriod {
+					t.Fatalf("history has %+v after an invalid pin", a)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package assigner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+)
+
+var ErrInvalidTarget = errors.New("invalid target")
+
+// SlotAssignment é o resultado de uma vaga da escala: a posição dela, como num
+// Pin, a sala e a função de onde saiu o designado.
+type SlotAssignment struct {
+	Pin
+	Hall     string `json:"hall,omitempty"`
+	Function string `json:"function"`
+}
+
+// Target escolhe as vagas a refazer: a semana inteira, uma parte dela ou só uma
+// vaga (holder ou assistant) da parte.
+type Target struct {
+	Week int    `json:"week"`
+	Part string `json:"part,omitempty"`
+	Slot string `json:"slot,omitempty"`
+}
+
+func (t Target) covers(week int, part, role string) bool {
+	return week == t.Week && (t.Part == "" || part == t.Part) && (t.Slot == "" || role == t.Slot)
+}
+
+// Reassign refaz só as vagas de target numa escala já gerada. As demais ficam
+// fixadas com os nomes de previous e quem ocupava uma vaga refeita não volta
+// para ela; a escolha segue as mesmas regras de AssignToMeetings, e o
+// histórico do período é regravado inteiro.
+func Reassign(meetings []parser.MeetingData, previous []SlotAssignment, target Target, pool map[string][]Designated, repo store.Repository, f *excelize.File, period string, opts Options) (*Result, error) {
+	found := false
+	for _, s := range planMeetings(meetings, Halls(opts.Classrooms)) {
+		if target.covers(s.week+1, s.part, s.role) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: week %d has no slot for part %q", ErrInvalidTarget, target.Week, PartID(target.Part))
+	}
+
+	opts.Pins, opts.replaced = nil, nil
+	for _, a := range previous {
+		if !target.covers(a.Week, a.Part, a.Slot) {
+			// Vagas que estavam vazias continuam vazias.
+			opts.Pins = append(opts.Pins, a.Pin)
+		} else if a.Name != "" {
+			opts.replaced = append(opts.replaced, a.Pin)
+		}
+	}
+	return AssignToMeetings(meetings, pool, repo, f, period, opts)
+}
+
+// ApplySlots grava uma escala já decidida vaga a vaga, como depois de uma troca
+// manual: ninguém é escolhido de novo, o histórico do período e as datas da
+// planilha são refeitos a partir de slots e as regras são conferidas como em
+// qualquer geração.
+func ApplySlots(meetings []parser.MeetingData, slots []SlotAssignment, pool map[string][]Designated, repo store.Repository, f *excelize.File, period string, opts Options) (*Result, error) {
+	opts.Pins, opts.replaced = nil, nil
+	for _, a := range slots {
+		opts.Pins = append(opts.Pins, a.Pin)
+	}
+	opts.Solver = false
+	return AssignToMeetings(meetings, pool, repo, f, period, opts)
+}
+
+// slotAssignments descreve a escala final, vaga a vaga.
+func slotAssignments(plan []slot, names []string) []SlotAssignment {
+	assignments := make([]SlotAssignment, len(plan))
+	for i, s := range plan {
+		assignments[i] = SlotAssignment{
+			Pin:      Pin{Week: s.week + 1, Part: s.part, Slot: s.role, Name: names[i]},
+			Hall:     s.hall,
+			Function: s.function,
+		}
+	}
+	return assignments
+}
@@ -0,0 +1,350 @@
+package assigner
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"sort"
+	"strings"
+)
+
+const profilesSheet = "Cadastro"
+
+// group é um grupo de publicadores que pode receber uma parte.
+type group string
+
+const (
+	groupBrother         group = "brother"
+	groupSister          group = "sister"
+	groupBaptizedBrother group = "baptized_brother"
+	groupAppointed       group = "elder_or_ministerial_servant"
+	groupElder           group = "elder"
+)
+
+// Regras das vagas que não são partes da apostila. As demais vagas usam o tipo
+// da parte como regra.
+const (
+	ruleChairman    = "chairman"
+	ruleCounselor   = "counselor"
+	rulePrayer      = "prayer"
+	ruleStudyReader = "study_reader"
+	ruleAssistant   = "assistant"
+)
+
+// eligibility mapeia cada regra para os grupos que podem receber a vaga; basta
+// pertencer a um deles. Regras fora da tabela aceitam qualquer publicador apto
+// na coluna da função. O ajudante de uma demonstração segue pairable.
+var eligibility = map[string][]group{
+	string(parser.KindTreasuresTalk):        {groupAppointed},
+	string(parser.KindSpiritualGems):        {groupAppointed},
+	string(parser.KindBibleReading):         {groupBrother},
+	string(parser.KindStartingConversation): {groupBrother, groupSister},
+	string(parser.KindFollowingUp):          {groupBrother, groupSister},
+	string(parser.KindMakingDisciples):      {groupBrother, groupSister},
+	string(parser.KindExplainingBeliefs):    {groupBrother, groupSister},
+	string(parser.KindMinistry):             {groupBrother, groupSister},
+	string(parser.KindStudentTalk):          {groupBrother},
+	string(parser.KindLocalNeeds):           {groupElder},
+	string(parser.KindChristianLiving):      {groupAppointed},
+	string(parser.KindCongregationStudy):    {groupAppointed},
+	ruleStudyReader:                         {groupBaptizedBrother},
+	ruleChairman:                            {groupElder},
+	ruleCounselor:                           {groupElder},
+	rulePrayer:                              {groupBaptizedBrother},
+}
+
+// includes diz se o publicador pertence ao grupo. Quem é só estudante fica
+// restrito às partes de estudante. Sem a aba "Cadastro" só há o gênero deduzido
+// das colunas (Homem) e (Mulher): gênero vazio não exclui ninguém e a coluna da
+// função decide. Com o cadastro, quem ficou de fora dele já foi barrado em
+// profiles.eligible.
+func (g group) includes(a store.Attributes) bool {
+	brother := a.Gender != store.GenderFemale
+	switch g {
+	case groupBrother:
+		return brother
+	case groupSister:
+		return a.Gender != store.GenderMale
+	case groupBaptizedBrother:
+		return brother && (!a.Registered || (a.Baptized && !a.StudentOnly))
+	case groupAppointed:
+		return brother && (!a.Registered || (a.Appointment != "" && !a.StudentOnly))
+	case groupElder:
+		return brother && (!a.Registered || a.Appointment == store.AppointmentElder)
+	}
+	return false
+}
+
+// profiles são os atributos de cada publicador do pool e as preferências de
+// par cadastradas. registered indica que o pool tem cadastro: alguém veio da
+// aba "Cadastro".
+type profiles struct {
+	people     map[string]store.Attributes
+	registered bool
+	preferred  map[pair]bool
+	forbidden  map[pair]bool
+}
+
+func newProfiles(pool map[string][]Designated, pairings []store.Pairing) profiles {
+	p := profiles{
+		people:    make(map[string]store.Attributes),
+		preferred: make(map[pair]bool),
+		forbidden: make(map[pair]bool),
+	}
+	for _, list := range pool {
+		for _, designated := range list {
+			p.people[designated.Name] = designated.Attributes
+			if designated.Registered {
+				p.registered = true
+			}
+		}
+	}
+	for _, pairing := range pairings {
+		switch pairing.Kind {
+		case store.PairingPreferred:
+			p.preferred[newPair(pairing.First, pairing.Second)] = true
+		case store.PairingForbidden:
+			p.forbidden[newPair(pairing.First, pairing.Second)] = true
+		}
+	}
+	return p
+}
+
+// eligible diz se name pode receber a vaga s. Havendo cadastro, quem não está
+// nele não recebe vaga nenhuma: sem gênero, batismo e privilégio conhecidos, as
+// regras não têm como ser conferidas.
+func (p profiles) eligible(s slot, name string) bool {
+	if p.unlisted(name) {
+		return false
+	}
+	groups, ok := eligibility[s.rule]
+	if !ok {
+		return true
+	}
+	for _, g := range groups {
+		if g.includes(p.people[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+// unlisted diz se o pool tem cadastro e name não está nele.
+func (p profiles) unlisted(name string) bool {
+	return p.registered && !p.people[name].Registered
+}
+
+// pairIssue explica por que assistant não pode ajudar holder, ou devolve vazio
+// se o par é permitido: o par não pode estar proibido e os dois precisam ser do
+// mesmo gênero, quando ele é conhecido, ou da mesma família.
+func (p profiles) pairIssue(holder, assistant string) string {
+	if p.forbidden[newPair(holder, assistant)] {
+		return "forbidden pairing"
+	}
+	h, a := p.people[holder], p.people[assistant]
+	if h.Gender == "" || a.Gender == "" || h.Gender == a.Gender {
+		return ""
+	}
+	if h.Household != "" && h.Household == a.Household {
+		return ""
+	}
+	return "brother and sister from different households"
+}
+
+func (p profiles) pairable(holder, assistant string) bool {
+	return p.pairIssue(holder, assistant) == ""
+}
+
+// forbiddenPair diz se name, na vaga idx, faria um par proibido com o parceiro
+// já escolhido ou fixado. Ao contrário das outras regras, o par proibido nunca
+// é relaxado na escolha forçada.
+func (p profiles) forbiddenPair(plan []slot, names []string, idx int, name string) bool {
+	s := plan[idx]
+	if s.rule == ruleAssistant && s.distinctFrom >= 0 && names[s.distinctFrom] != "" {
+		return p.forbidden[newPair(names[s.distinctFrom], name)]
+	}
+	for j := idx + 1; j < len(plan) && plan[j].week == s.week; j++ {
+		if plan[j].pinned && plan[j].rule == ruleAssistant && plan[j].distinctFrom == idx && names[j] != "" {
+			return p.forbidden[newPair(name, names[j])]
+		}
+	}
+	return false
+}
+
+// allows junta as regras da vaga idx para name, considerando o titular já
+// escolhido quando a vaga é de ajudante.
+func (p profiles) allows(plan []slot, names []string, idx int, name string) bool {
+	s := plan[idx]
+	if !p.eligible(s, name) {
+		return false
+	}
+	if s.rule == ruleAssistant && s.distinctFrom >= 0 && names[s.distinctFrom] != "" {
+		return p.pairable(names[s.distinctFrom], name)
+	}
+	return true
+}
+
+// inferGender deduz o gênero das colunas (Homem) e (Mulher) em que o
+// publicador está apto, para quem não tem gênero no cadastro.
+func inferGender(functions []string) string {
+	gender := ""
+	for _, function := range functions {
+		found := ""
+		switch {
+		case strings.HasSuffix(function, "(Homem)"):
+			found = store.GenderMale
+		case strings.HasSuffix(function, "(Mulher)"):
+			found = store.GenderFemale
+		default:
+			continue
+		}
+		if gender != "" && gender != found {
+			return ""
+		}
+		gender = found
+	}
+	return gender
+}
+
+// readProfiles lê a aba opcional "Cadastro" da planilha de designados, com as
+// colunas Publicador, Sexo, Batizado, Privilégio, Só estudante e Família. O
+// segundo retorno é falso se a aba não existir.
+func readProfiles(f *excelize.File) (map[string]store.Attributes, bool, error) {
+	if idx, err := f.GetSheetIndex(profilesSheet); err != nil || idx == -1 {
+		return nil, false, nil
+	}
+
+	rows, err := f.GetRows(profilesSheet)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading tab %s: %v", profilesSheet, err)
+	}
+	result := make(map[string]store.Attributes)
+	if len(rows) == 0 {
+		return result, true, nil
+	}
+
+	nameIdx, genderIdx, baptizedIdx, appointmentIdx, studentIdx, householdIdx := -1, -1, -1, -1, -1, -1
+	for idx, header := range rows[0] {
+		switch normalizeHeader(header) {
+		case "publicador", "publicadores", "nome":
+			nameIdx = idx
+		case "sexo", "genero":
+			genderIdx = idx
+		case "batizado":
+			baptizedIdx = idx
+		case "privilegio", "nomeacao":
+			appointmentIdx = idx
+		case "so estudante", "estudante":
+			studentIdx = idx
+		case "familia":
+			householdIdx = idx
+		}
+	}
+	if nameIdx == -1 || genderIdx == -1 {
+		return nil, false, fmt.Errorf("tab %s must have 'Publicador' and 'Sexo' columns", profilesSheet)
+	}
+
+	for rowIdx, row := range rows[1:] {
+		name := cellAt(row, nameIdx)
+		if name == "" {
+			continue
+		}
+		attributes := store.Attributes{Registered: true, Household: cellAt(row, householdIdx)}
+		if attributes.Gender, err = parseGender(cellAt(row, genderIdx)); err != nil {
+			return nil, false, fmt.Errorf("tab %s, row %d: %w", profilesSheet, rowIdx+2, err)
+		}
+		if attributes.Baptized, err = parseYesNo(cellAt(row, baptizedIdx)); err != nil {
+			return nil, false, fmt.Errorf("tab %s, row %d: %w", profilesSheet, rowIdx+2, err)
+		}
+		if attributes.Appointment, err = parseAppointment(cellAt(row, appointmentIdx)); err != nil {
+			return nil, false, fmt.Errorf("tab %s, row %d: %w", profilesSheet, rowIdx+2, err)
+		}
+		if attributes.StudentOnly, err = parseYesNo(cellAt(row, studentIdx)); err != nil {
+			return nil, false, fmt.Errorf("tab %s, row %d: %w", profilesSheet, rowIdx+2, err)
+		}
+		result[name] = attributes
+	}
+	return result, true, nil
+}
+
+func parseGender(value string) (string, error) {
+	switch normalizeHeader(value) {
+	case "m", "h", "masculino", "homem", "irmao", "male":
+		return store.GenderMale, nil
+	case "f", "feminino", "mulher", "irma", "female":
+		return store.GenderFemale, nil
+	}
+	return "", fmt.Errorf("unknown gender %q", value)
+}
+
+func parseAppointment(value string) (string, error) {
+	switch normalizeHeader(value) {
+	case "", "-", "publicador", "nenhum":
+		return "", nil
+	case "anciao", "elder":
+		return store.AppointmentElder, nil
+	case "servo ministerial", "servo", "sm", "ministerial servant", "ms":
+		return store.AppointmentMinisterialServant, nil
+	}
+	return "", fmt.Errorf("unknown appointment %q", value)
+}
+
+func parseYesNo(value string) (bool, error) {
+	switch normalizeHeader(value) {
+	case "", "nao", "n", "0", "false", "no":
+		return false, nil
+	case "sim", "s", "1", "true", "x", "yes":
+		return true, nil
+	}
+	return false, fmt.Errorf("expected yes or no, got %q", value)
+}
+
+// groupPool acrescenta ao pool as filas combinadas de functionGroups, com os
+// designados das colunas de cada grupo ordenados pela última designação.
+func groupPool(pool map[string][]Designated, shuffle func([]Designated)) {
+	keys := make([]string, 0, len(functionGroups))
+	for key := range functionGroups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, ok := pool[key]; ok {
+			continue
+		}
+		seen := make(map[string]bool)
+		var list []Designated
+		for _, function := range functionGroups[key] {
+			for _, designated := range pool[function] {
+				if seen[designated.Name] {
+					continue
+				}
+				seen[designated.Name] = true
+				list = append(list, designated)
+			}
+		}
+		if len(list) == 0 {
+			continue
+		}
+		shuffle(list)
+		sort.SliceStable(list, func(i, j int) bool {
+			return compareByDatePriority(list[i], list[j])
+		})
+		pool[key] = list
+	}
+}
+
+// functionIncludes diz se a coluna column pertence à função function, que pode
+// ser uma fila combinada.
+func functionIncludes(function, column string) bool {
+	if column == function {
+		return true
+	}
+	for _, member := range functionGroups[function] {
+		if member == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package assigner
+
+import (
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestEligible(t *testing.T) {
+	elder := store.Attributes{Registered: true, Gender: store.GenderMale, Baptized: true, Appointment: store.AppointmentElder}
+	servant := store.Attributes{Registered: true, Gender: store.GenderMale, Baptized: true, Appointment: store.AppointmentMinisterialServant}
+	brother := store.Attributes{Registered: true, Gender: store.GenderMale, Baptized: true}
+	unbaptized := store.Attributes{Registered: true, Gender: store.GenderMale}
+	student := store.Attributes{Registered: true, Gender: store.GenderMale, Baptized: true, StudentOnly: true}
+	sister := store.Attributes{Registered: true, Gender: store.GenderFemale, Baptized: true}
+	unknown := store.Attributes{}
+
+	tests := []struct {
+		name       string
+		attributes store.Attributes
+		registered bool
+		rule       string
+		want       bool
+	}{
+		{"elder as chairman", elder, true, ruleChairman, true},
+		{"servant as chairman", servant, true, ruleChairman, false},
+		{"servant gives the treasures talk", servant, true, string(parser.KindTreasuresTalk), true},
+		{"brother gives the treasures talk", brother, true, string(parser.KindTreasuresTalk), false},
+		{"servant with local needs", servant, true, string(parser.KindLocalNeeds), false},
+		{"brother reads the Bible", brother, true, string(parser.KindBibleReading), true},
+		{"unbaptized brother reads the Bible", unbaptized, true, string(parser.KindBibleReading), true},
+		{"sister reads the Bible", sister, true, string(parser.KindBibleReading), false},
+		{"sister starts a conversation", sister, true, string(parser.KindStartingConversation), true},
+		{"sister gives a student talk", sister, true, string(parser.KindStudentTalk), false},
+		{"baptized brother prays", brother, true, rulePrayer, true},
+		{"unbaptized brother prays", unbaptized, true, rulePrayer, false},
+		{"sister prays", sister, true, rulePrayer, false},
+		{"student only prays", student, true, rulePrayer, false},
+		{"student only reads the study", student, true, ruleStudyReader, false},
+		{"student only starts a conversation", student, true, string(parser.KindStartingConversation), true},
+		{"unregistered as chairman", unknown, true, ruleChairman, false},
+		{"unregistered starts a conversation", unknown, true, string(parser.KindStartingConversation), false},
+		{"unregistered assists", unknown, true, ruleAssistant, false},
+		{"no register, unknown gender as chairman", unknown, false, ruleChairman, true},
+		{"no register, inferred sister prays", store.Attributes{Gender: store.GenderFemale}, false, rulePrayer, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := profiles{people: map[string]store.Attributes{"Ana": tt.attributes}, registered: tt.registered}
+			if got := p.eligible(slot{rule: tt.rule}, "Ana"); got != tt.want {
+				t.Errorf("eligible = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// addProfiles acrescenta a aba "Cadastro" com os publicadores dados; os
+// demais ficam fora do cadastro.
+func addProfiles(t *testing.T, f *excelize.File, rows [][]string) {
+	t.Helper()
+	if _, err := f.NewSheet(profilesSheet); err != nil {
+		t.Fatal(err)
+	}
+	header := []string{"Publicador", "Sexo", "Batizado", "Privilégio", "Só estudante", "Família"}
+	for i, row := range append([][]string{header}, rows...) {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+1)
+			f.SetCellValue(profilesSheet, cell, value)
+		}
+	}
+}
+
+// registeredProfiles cadastra names como anciãos batizados, menos skip.
+func registeredProfiles(names []string, skip string) [][]string {
+	var rows [][]string
+	for _, name := range names {
+		if name != skip {
+			rows = append(rows, []string{name, "M", "Sim", "Ancião", "Não", ""})
+		}
+	}
+	return rows
+}
+
+func TestUnlistedPublisherGetsNoPart(t *testing.T) {
+	names := testNames(30)
+	f := testSheet(t, names, func(row, function int) bool { return true })
+	addProfiles(t, f, registeredProfiles(names, "Pub05"))
+
+	result := generate(t, f, 2, 1, Options{})
+	for _, s := range result.Slots {
+		if s.Name == "Pub05" {
+			t.Errorf("Pub05 is not in %s but got %s in week %d", profilesSheet, s.Part, s.Week)
+		}
+	}
+	for _, c := range result.Conflicts {
+		if c.Kind == ConflictNotListed {
+			t.Errorf("unexpected conflict %+v", c)
+		}
+	}
+}
+
+func TestUnlistedPinnedPublisherIsConflict(t *testing.T) {
+	names := testNames(30)
+	f := testSheet(t, names, func(row, function int) bool { return true })
+	addProfiles(t, f, registeredProfiles(names, "Pub05"))
+
+	pin := Pin{Week: 1, Part: FUNC_PRESIDENTE, Name: "Pub05"}
+	result := generate(t, f, 1, 1, Options{Pins: []Pin{pin}})
+	found := false
+	for _, c := range result.Conflicts {
+		if c.Kind == ConflictNotListed && c.Name == "Pub05" && c.Part == FUNC_PRESIDENTE {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("conflicts %+v, want not_listed for Pub05 as chairman", result.Conflicts)
+	}
+}
+
+func TestImportDesignatesMissingColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+	}{
+		{"no publishers column", []string{"Nome", FUNC_PRESIDENTE, "Última"}},
+		{"no function columns", []string{"Publicadores"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := excelize.NewFile()
+			for i, header := range tt.headers {
+				cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+				f.SetCellValue(sheetName, cell, header)
+			}
+			f.SetCellValue(sheetName, "A2", "Pub00")
+			f.SetCellValue(sheetName, "B2", "1")
+
+			if err := ImportDesignatesFromFile(f, newRepository(t)); err == nil {
+				t.Error("import succeeded, want an error")
+			}
+		})
+	}
+}
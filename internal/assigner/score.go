@@ -0,0 +1,113 @@
+package assigner
+
+import (
+	"sort"
+	"time"
+)
+
+// maxRecencyDays limita a recência de quem nunca foi designado, para que
+// ninguém fique com pontuação infinita.
+const maxRecencyDays = 365
+
+// Weights define o peso de cada fator na pontuação de um candidato. Recências
+// somam pontos por dia: na função da vaga (RoleRecency), no tipo de vaga,
+// titular ou ajudante (SlotRecency), e em qualquer designação (AnyRecency).
+// Contagem nos últimos seis meses, contagem no período e carga na semana
+// subtraem por designação. Para ajudantes, um par preferido soma PreferredPair
+// e repetir o par do último mês subtrai RepeatPair.
+type Weights struct {
+	RoleRecency   float64 `json:"role_recency"`
+	SlotRecency   float64 `json:"slot_recency"`
+	AnyRecency    float64 `json:"any_recency"`
+	RecentCount   float64 `json:"recent_count"`
+	PeriodCount   float64 `json:"period_count"`
+	WeekLoad      float64 `json:"week_load"`
+	PreferredPair float64 `json:"preferred_pair"`
+	RepeatPair    float64 `json:"repeat_pair"`
+}
+
+var DefaultWeights = Weights{
+	RoleRecency:   1,
+	SlotRecency:   0.25,
+	AnyRecency:    0.5,
+	RecentCount:   10,
+	PeriodCount:   20,
+	WeekLoad:      60,
+	PreferredPair: 60,
+	RepeatPair:    90,
+}
+
+type Score struct {
+	Name          string  `json:"name"`
+	Total         float64 `json:"total"`
+	DaysSinceRole int     `json:"days_since_role"`
+	DaysSinceSlot int     `json:"days_since_slot"`
+	DaysSinceAny  int     `json:"days_since_any"`
+	RecentCount   int     `json:"recent_count"`
+	PeriodCount   int     `json:"period_count"`
+	WeekLoad      int     `json:"week_load"`
+	PreferredPair bool    `json:"preferred_pair,omitempty"`
+	RepeatPair    bool    `json:"repeat_pair,omitempty"`
+}
+
+// Decision explica uma escolha: a vaga, quem foi escolhido e a pontuação de
+// todos os candidatos considerados, da maior para a menor.
+type Decision struct {
+	Week       string  `json:"week"`
+	Part       string  `json:"part"`
+	Function   string  `json:"function"`
+	Role       string  `json:"role"`
+	Hall       string  `json:"hall,omitempty"`
+	Chosen     string  `json:"chosen"`
+	Forced     bool    `json:"forced,omitempty"`
+	Pinned     bool    `json:"pinned,omitempty"`
+	Candidates []Score `json:"candidates"`
+}
+
+// score pontua name para a vaga; partner é o titular já escolhido quando a
+// vaga é de ajudante de demonstração.
+func (w Weights) score(h *history, name string, s slot, partner string) Score {
+	sc := Score{
+		Name:          name,
+		DaysSinceRole: daysSince(h.lastInFunction(name, s.function), s.date),
+		DaysSinceSlot: daysSince(h.lastInRole(name, s.role), s.date),
+		DaysSinceAny:  daysSince(h.lastAny(name), s.date),
+		RecentCount:   h.countSince(name, s.date.Add(-frequencyWindow)),
+		PeriodCount:   h.countInPeriod(name),
+		WeekLoad:      h.countInWeek(name, s.meeting),
+	}
+	if partner != "" {
+		sc.PreferredPair = h.profiles.preferred[newPair(partner, name)]
+		sc.RepeatPair = h.recentPair(partner, name, s)
+	}
+	sc.Total = w.RoleRecency*float64(sc.DaysSinceRole) +
+		w.SlotRecency*float64(sc.DaysSinceSlot) +
+		w.AnyRecency*float64(sc.DaysSinceAny) -
+		w.RecentCount*float64(sc.RecentCount) -
+		w.PeriodCount*float64(sc.PeriodCount) -
+		w.WeekLoad*float64(sc.WeekLoad)
+	if sc.PreferredPair {
+		sc.Total += w.PreferredPair
+	}
+	if sc.RepeatPair {
+		sc.Total -= w.RepeatPair
+	}
+	return sc
+}
+
+func daysSince(last, date time.Time) int {
+	if last.IsZero() {
+		return maxRecencyDays
+	}
+	days := int(date.Sub(last).Hours() / 24)
+	if days > maxRecencyDays {
+		return maxRecencyDays
+	}
+	return days
+}
+
+func sortScores(scores []Score) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Total > scores[j].Total
+	})
+}
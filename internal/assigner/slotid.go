@@ -0,0 +1,45 @@
+package assigner
+
+// Identificadores estáveis das vagas da reunião que não são partes numeradas.
+// A API usa estes no lugar das chaves internas, que seguem os nomes das
+// colunas da planilha; as partes numeradas ("4", "4.A") já são estáveis.
+const (
+	PartChairman      = "chairman"
+	PartCounselorB    = "counselor.B"
+	PartCounselorC    = "counselor.C"
+	PartOpeningPrayer = "opening_prayer"
+	PartClosingPrayer = "closing_prayer"
+)
+
+var partIDs = map[string]string{
+	FUNC_PRESIDENTE:    PartChairman,
+	FUNC_CONSELHEIRO:   PartCounselorB,
+	FUNC_CONSELHEIRO_C: PartCounselorC,
+	FUNC_ORACAO:        PartOpeningPrayer,
+	FUNC_ORACAO_FINAL:  PartClosingPrayer,
+}
+
+var partKeys = func() map[string]string {
+	keys := make(map[string]string, len(partIDs))
+	for key, id := range partIDs {
+		keys[id] = key
+	}
+	return keys
+}()
+
+// PartID devolve o identificador estável da chave interna de uma vaga.
+func PartID(key string) string {
+	if id, ok := partIDs[key]; ok {
+		return id
+	}
+	return key
+}
+
+// PartKey devolve a chave interna do identificador estável de uma vaga; chaves
+// internas passam sem mudança.
+func PartKey(id string) string {
+	if key, ok := partKeys[id]; ok {
+		return key
+	}
+	return id
+}
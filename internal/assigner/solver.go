@@ -0,0 +1,390 @@
+package assigner
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// DefaultSolverIterations é o número de passos da busca quando a geração não
+// informa outro.
+const DefaultSolverIterations = 20000
+
+const (
+	solverTemperature = 50.0
+
+	conflictPenalty = 1e6
+	// avoidPenalty pesa mais que uma vaga vazia: quem saiu de uma vaga refeita
+	// não volta para ela nem para tirar a vaga do vazio.
+	avoidPenalty   = 2 * conflictPenalty
+	repeatPenalty  = 40.0
+	spacingPenalty = 100.0
+	preferredBonus = 20.0
+)
+
+// solve parte da escala gulosa e busca, por recozimento simulado, uma escala do
+// período inteiro com menos repetições e mais espaço entre as designações de
+// cada pessoa. Cada passo troca o designado de uma vaga por outro apto ou troca
+// os designados de duas vagas da mesma função; vagas fixadas não mudam.
+//
+// Um passo que formaria um par proibido é descartado sem avaliar a escala, então
+// a busca só cria um par proibido se a escala gulosa já o tiver.
+//
+// A busca para num número fixo de passos, nunca pelo relógio, então a mesma
+// semente e o mesmo número de passos dão sempre a mesma escala.
+func solve(plan []slot, initial []string, pool map[string][]Designated, h *history, opts Options) []string {
+	if len(plan) == 0 {
+		return initial
+	}
+
+	iterations := opts.SolverIterations
+	if iterations <= 0 {
+		iterations = DefaultSolverIterations
+	}
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(0))
+	}
+	model := newCostModel(plan, initial, pool, h.lastBeforePeriod(), h.absences, h.profiles)
+	model.setPairs(h.pairsBeforePeriod())
+
+	candidates := make([][]int, len(plan))
+	sameFunction := make(map[string][]int)
+	for i, s := range plan {
+		if s.pinned {
+			continue
+		}
+		for _, designated := range pool[s.function] {
+			if designated.Name == s.avoid {
+				continue
+			}
+			candidates[i] = append(candidates[i], model.ids[designated.Name])
+		}
+		sameFunction[s.function] = append(sameFunction[s.function], i)
+	}
+
+	current := model.encode(initial)
+	currentCost := model.cost(current)
+	best := append([]int(nil), current...)
+	bestCost := currentCost
+
+	for it := 0; it < iterations; it++ {
+		i := rng.Intn(len(plan))
+		if plan[i].pinned {
+			continue
+		}
+		j := -1
+		previous := current[i]
+		if peers := sameFunction[plan[i].function]; len(peers) > 1 && rng.Intn(2) == 0 {
+			j = peers[rng.Intn(len(peers))]
+			if j == i {
+				continue
+			}
+			current[i], current[j] = current[j], current[i]
+		} else {
+			if len(candidates[i]) == 0 {
+				continue
+			}
+			current[i] = candidates[i][rng.Intn(len(candidates[i]))]
+		}
+
+		if !model.forbiddenAt(current, i) && (j < 0 || !model.forbiddenAt(current, j)) {
+			cost := model.cost(current)
+			temperature := solverTemperature * (1 - float64(it)/float64(iterations))
+			if cost <= currentCost || (temperature > 0 && rng.Float64() < math.Exp((currentCost-cost)/temperature)) {
+				currentCost = cost
+				if cost < bestCost {
+					bestCost = cost
+					copy(best, current)
+				}
+				continue
+			}
+		}
+
+		if j >= 0 {
+			current[i], current[j] = current[j], current[i]
+		} else {
+			current[i] = previous
+		}
+	}
+
+	return model.decode(best)
+}
+
+// costModel avalia escalas completas. Pessoas e funções viram índices para
+// que cada avaliação só percorra slices, sem alocar.
+type costModel struct {
+	plan       []slot
+	people     []string
+	ids        map[string]int
+	functions  []int
+	lastBefore []time.Time
+	weeks      int
+	// unavailable marca, por vaga e pessoa, quem está indisponível na semana.
+	unavailable []bool
+	// ineligible marca, por vaga e pessoa, quem não pode receber a parte.
+	ineligible []bool
+	// avoided é, por vaga, quem ocupava a vaga refeita, ou -1.
+	avoided  []int
+	profiles profiles
+	// pairedBefore é a última vez de cada par antes do período; preferred e
+	// forbidden marcam os pares preferidos e proibidos. As chaves são ids de
+	// pessoa em ordem.
+	pairedBefore map[[2]int]time.Time
+	preferred    map[[2]int]bool
+	forbidden    map[[2]int]bool
+	pairSeen     map[[2]int]bool
+	// assistantOf é, por vaga de titular, a vaga do ajudante, ou -1.
+	assistantOf []int
+
+	exclusiveUse  []int
+	weekLoad      []int
+	functionCount []int
+	functionTotal int
+	dates         [][]time.Time
+}
+
+func newCostModel(plan []slot, initial []string, pool map[string][]Designated, lastBefore map[string]time.Time, absences availability, people profiles) *costModel {
+	known := make(map[string]bool)
+	for _, list := range pool {
+		for _, designated := range list {
+			known[designated.Name] = true
+		}
+	}
+	for _, name := range initial {
+		if name != "" {
+			known[name] = true
+		}
+	}
+
+	m := &costModel{plan: plan, ids: make(map[string]int, len(known)), profiles: people}
+	for name := range known {
+		m.people = append(m.people, name)
+	}
+	sort.Strings(m.people)
+	for i, name := range m.people {
+		m.ids[name] = i
+		m.lastBefore = append(m.lastBefore, lastBefore[name])
+	}
+
+	functionIDs := make(map[string]int)
+	for _, s := range plan {
+		if _, ok := functionIDs[s.function]; !ok {
+			functionIDs[s.function] = len(functionIDs)
+		}
+		m.functions = append(m.functions, functionIDs[s.function])
+		if s.week+1 > m.weeks {
+			m.weeks = s.week + 1
+		}
+	}
+	m.functionTotal = len(functionIDs)
+
+	m.unavailable = make([]bool, len(plan)*len(m.people))
+	for name := range absences {
+		id, ok := m.ids[name]
+		if !ok {
+			continue
+		}
+		for i, s := range plan {
+			m.unavailable[i*len(m.people)+id] = !absences.available(name, s)
+		}
+	}
+
+	m.ineligible = make([]bool, len(plan)*len(m.people))
+	for i, s := range plan {
+		for id, name := range m.people {
+			m.ineligible[i*len(m.people)+id] = !people.eligible(s, name)
+		}
+	}
+
+	m.assistantOf = make([]int, len(plan))
+	for i := range m.assistantOf {
+		m.assistantOf[i] = -1
+	}
+	for i, s := range plan {
+		if s.rule == ruleAssistant && s.distinctFrom >= 0 {
+			m.assistantOf[s.distinctFrom] = i
+		}
+	}
+
+	m.avoided = make([]int, len(plan))
+	for i, s := range plan {
+		m.avoided[i] = -1
+		if id, ok := m.ids[s.avoid]; ok && s.avoid != "" {
+			m.avoided[i] = id
+		}
+	}
+
+	m.exclusiveUse = make([]int, m.weeks*len(m.people))
+	m.weekLoad = make([]int, m.weeks*len(m.people))
+	m.functionCount = make([]int, m.functionTotal*len(m.people))
+	m.dates = make([][]time.Time, len(m.people))
+	return m
+}
+
+func (m *costModel) setPairs(before map[pair]time.Time) {
+	m.pairedBefore = make(map[[2]int]time.Time)
+	m.preferred = make(map[[2]int]bool)
+	m.forbidden = make(map[[2]int]bool)
+	m.pairSeen = make(map[[2]int]bool)
+	for p, date := range before {
+		if key, ok := m.pairKey(p); ok {
+			m.pairedBefore[key] = date
+		}
+	}
+	for p := range m.profiles.preferred {
+		if key, ok := m.pairKey(p); ok {
+			m.preferred[key] = true
+		}
+	}
+	for p := range m.profiles.forbidden {
+		if key, ok := m.pairKey(p); ok {
+			m.forbidden[key] = true
+		}
+	}
+}
+
+// forbiddenAt diz se a vaga i, de titular ou de ajudante, está num par
+// proibido.
+func (m *costModel) forbiddenAt(ids []int, i int) bool {
+	holder, assistant := i, m.assistantOf[i]
+	if m.plan[i].rule == ruleAssistant {
+		holder, assistant = m.plan[i].distinctFrom, i
+	}
+	if holder < 0 || assistant < 0 || ids[holder] < 0 || ids[assistant] < 0 {
+		return false
+	}
+	return m.forbidden[orderedIDs(ids[holder], ids[assistant])]
+}
+
+func (m *costModel) pairKey(p pair) ([2]int, bool) {
+	a, okA := m.ids[p[0]]
+	b, okB := m.ids[p[1]]
+	if !okA || !okB {
+		return [2]int{}, false
+	}
+	return orderedIDs(a, b), true
+}
+
+func orderedIDs(a, b int) [2]int {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+func (m *costModel) encode(names []string) []int {
+	encoded := make([]int, len(names))
+	for i, name := range names {
+		if id, ok := m.ids[name]; ok && name != "" {
+			encoded[i] = id
+		} else {
+			encoded[i] = -1
+		}
+	}
+	return encoded
+}
+
+func (m *costModel) decode(ids []int) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		if id >= 0 {
+			names[i] = m.people[id]
+		}
+	}
+	return names
+}
+
+// cost soma as penalidades da escala: conflitos (vaga vazia, pessoa repetida
+// na semana ou no par, pessoa indisponível ou fora das regras da parte) pesam
+// muito mais que repetições no período, pares repetidos e designações próximas
+// umas das outras.
+func (m *costModel) cost(ids []int) float64 {
+	clear(m.exclusiveUse)
+	clear(m.weekLoad)
+	clear(m.functionCount)
+	clear(m.pairSeen)
+	for i := range m.dates {
+		m.dates[i] = m.dates[i][:0]
+	}
+
+	people := len(m.people)
+	cost := 0.0
+	for i, s := range m.plan {
+		id := ids[i]
+		if id < 0 {
+			cost += conflictPenalty
+			continue
+		}
+		if s.distinctFrom >= 0 && ids[s.distinctFrom] == id {
+			cost += conflictPenalty
+		}
+		if m.unavailable[i*people+id] || m.ineligible[i*people+id] {
+			cost += conflictPenalty
+		}
+		if id == m.avoided[i] {
+			cost += avoidPenalty
+		}
+		if s.rule == ruleAssistant && s.distinctFrom >= 0 && ids[s.distinctFrom] >= 0 {
+			cost += m.pairCost(s, ids[s.distinctFrom], id)
+		}
+
+		wn := s.week*people + id
+		if s.exclusive {
+			if m.exclusiveUse[wn] > 0 {
+				cost += conflictPenalty
+			}
+			m.exclusiveUse[wn]++
+		}
+		m.weekLoad[wn]++
+		if m.weekLoad[wn] > 1 {
+			cost += repeatPenalty
+		}
+
+		nf := m.functions[i]*people + id
+		m.functionCount[nf]++
+		if m.functionCount[nf] > 1 {
+			cost += repeatPenalty
+		}
+		m.dates[id] = append(m.dates[id], s.date)
+	}
+
+	for id, ds := range m.dates {
+		if len(ds) == 0 {
+			continue
+		}
+		cost += repeatPenalty * float64((len(ds)-1)*(len(ds)-1))
+
+		// As vagas estão em ordem de semana, então as datas já vêm ordenadas.
+		previous := m.lastBefore[id]
+		for _, d := range ds {
+			if !previous.IsZero() {
+				weeks := d.Sub(previous).Hours() / (24 * 7)
+				cost += spacingPenalty / (1 + math.Max(weeks, 0))
+			}
+			previous = d
+		}
+	}
+
+	return cost
+}
+
+// pairCost avalia o par de demonstração: par proibido ou de gênero e família
+// diferentes é conflito; repetir um par do último mês ou do próprio período
+// pesa como repetição e um par preferido reduz o custo.
+func (m *costModel) pairCost(s slot, holder, assistant int) float64 {
+	if !m.profiles.pairable(m.people[holder], m.people[assistant]) {
+		return conflictPenalty
+	}
+	key := orderedIDs(holder, assistant)
+	cost := 0.0
+	if last, ok := m.pairedBefore[key]; (ok && daysSince(last, s.date) < recentPairDays) || m.pairSeen[key] {
+		cost += repeatPenalty
+	}
+	m.pairSeen[key] = true
+	if m.preferred[key] {
+		cost -= preferredBonus
+	}
+	return cost
+}
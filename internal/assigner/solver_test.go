@@ -0,0 +1,93 @@
+package assigner
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// countingSource conta os números tirados do gerador.
+type countingSource struct {
+	rand.Source
+	calls int
+}
+
+func (s *countingSource) Int63() int64 {
+	s.calls++
+	return s.Source.Int63()
+}
+
+// greedySchedule monta a escala gulosa de AssignToMeetings, sem o otimizador,
+// e devolve o que solve precisa para partir dela.
+func greedySchedule(t *testing.T, weeks int) ([]slot, []string, map[string][]Designated, *history) {
+	t.Helper()
+	// Poucos publicadores para quatro semanas: a escala gulosa repete gente.
+	f := testSheet(t, testNames(20), func(row, function int) bool { return (row+function)%3 != 0 })
+	repo, pool := testRepository(t, f, 3)
+
+	h, err := newHistory(repo, f, testPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.weights = DefaultWeights
+	h.absences = newAvailability(nil)
+	rng := rand.New(rand.NewSource(3))
+	groupPool(pool, func(list []Designated) { shuffleDesignated(list, rng) })
+	h.profiles = newProfiles(pool, nil)
+
+	plan := planMeetings(testMeetings(t, weeks), Halls(0))
+	names := make([]string, len(plan))
+	for i := range plan {
+		names[i] = h.pick(plan, names, i, pool)
+	}
+	return plan, names, pool, h
+}
+
+func scheduleCost(plan []slot, names []string, pool map[string][]Designated, h *history) float64 {
+	model := newCostModel(plan, names, pool, h.lastBeforePeriod(), h.absences, h.profiles)
+	model.setPairs(h.pairsBeforePeriod())
+	return model.cost(model.encode(names))
+}
+
+func TestSolveNeverWorseThanGreedy(t *testing.T) {
+	for _, seed := range []int64{1, 2, 3, 4, 5} {
+		plan, greedy, pool, h := greedySchedule(t, 4)
+		opts := Options{SolverIterations: 2000, Rand: rand.New(rand.NewSource(seed))}
+		solved := solve(plan, greedy, pool, h, opts)
+
+		before := scheduleCost(plan, greedy, pool, h)
+		after := scheduleCost(plan, solved, pool, h)
+		if after > before {
+			t.Errorf("seed %d: solver cost %.1f, greedy cost %.1f", seed, after, before)
+		}
+	}
+}
+
+// TestSolveStopsAtIterationBound confere que a busca dá exatamente o número de
+// passos pedido: cada passo tira no máximo quatro números do gerador e um
+// único passo muda no máximo duas vagas.
+func TestSolveStopsAtIterationBound(t *testing.T) {
+	plan, greedy, pool, h := greedySchedule(t, 4)
+
+	for _, iterations := range []int{1, 10, 500} {
+		source := &countingSource{Source: rand.NewSource(9)}
+		opts := Options{SolverIterations: iterations, Rand: rand.New(source)}
+		solve(plan, greedy, pool, h, opts)
+		if source.calls == 0 || source.calls > 5*iterations {
+			t.Errorf("%d iterations drew %d numbers, want between 1 and %d", iterations, source.calls, 5*iterations)
+		}
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		opts := Options{SolverIterations: 1, Rand: rand.New(rand.NewSource(seed))}
+		solved := solve(plan, greedy, pool, h, opts)
+		changed := 0
+		for i := range solved {
+			if solved[i] != greedy[i] {
+				changed++
+			}
+		}
+		if changed > 2 {
+			t.Errorf("seed %d: one iteration changed %d slots, want at most 2", seed, changed)
+		}
+	}
+}
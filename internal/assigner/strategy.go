@@ -3,19 +3,22 @@ package assigner
 import (
 	"fmt"
 	"github.com/xuri/excelize/v2"
+	"math/rand"
 	"midweek-project/internal/parser"
-	"regexp"
-	"sort"
+	"midweek-project/internal/store"
 	"strings"
+	"time"
 )
 
 const (
 	FUNC_PRESIDENTE          = "Presidente"
 	FUNC_CONSELHEIRO         = "Conselheiro Sala B"
+	FUNC_CONSELHEIRO_C       = "Conselheiro Sala C"
 	FUNC_ORACAO              = "Oração"
 	FUNC_ORACAO_FINAL        = "OraçãoFinal"
 	FUNC_LEITOR_BIBLIA_A     = "Leitor - Leitura da Bíblia - A"
 	FUNC_LEITOR_BIBLIA_B     = "Leitor - Leitura da Bíblia - B"
+	FUNC_LEITOR_BIBLIA_C     = "Leitor - Leitura da Bíblia - C"
 	FUNC_DISCURSO_TESOUROS   = "Discurso - Tesouros da Palavra de Deus"
 	FUNC_JOIAS               = "Joías Espirituais - Tesouros da Palavra de Deus"
 	FUNC_DISCURSO_MINISTERIO = "Discursos -  Faça Seu Melhor no Ministério"
@@ -30,9 +33,124 @@ const (
 	FUNC_AJUDANTE_B_HOMEM    = "Ajudante - B (Homem)"
 	FUNC_TITULAR_B_MULHER    = "Titular - B (Mulher)"
 	FUNC_AJUDANTE_B_MULHER   = "Ajudante - B (Mulher)"
+	FUNC_TITULAR_C_HOMEM     = "Titular - C (Homem)"
+	FUNC_AJUDANTE_C_HOMEM    = "Ajudante - C (Homem)"
+	FUNC_TITULAR_C_MULHER    = "Titular - C (Mulher)"
+	FUNC_AJUDANTE_C_MULHER   = "Ajudante - C (Mulher)"
+
+	// Filas combinadas das demonstrações, que juntam as colunas (Homem) e
+	// (Mulher) de cada sala.
+	FUNC_TITULAR_A  = "Titular - A"
+	FUNC_AJUDANTE_A = "Ajudante - A"
+	FUNC_TITULAR_B  = "Titular - B"
+	FUNC_AJUDANTE_B = "Ajudante - B"
+	FUNC_TITULAR_C  = "Titular - C"
+	FUNC_AJUDANTE_C = "Ajudante - C"
 )
 
-func AssignToMeetings(meetings []parser.MeetingData, pool map[string][]Designated, f *excelize.File) ([]parser.MeetingData, error) {
+const (
+	MinClassrooms     = 1
+	MaxClassrooms     = 3
+	DefaultClassrooms = 2
+)
+
+// hallFunctions são as funções de cada sala: o leitor e as filas combinadas de
+// titular e ajudante das demonstrações.
+type hallFunctions struct {
+	reader, holder, helper string
+}
+
+var functionsByHall = map[string]hallFunctions{
+	store.HallMain:      {FUNC_LEITOR_BIBLIA_A, FUNC_TITULAR_A, FUNC_AJUDANTE_A},
+	store.HallAuxiliary: {FUNC_LEITOR_BIBLIA_B, FUNC_TITULAR_B, FUNC_AJUDANTE_B},
+	store.HallThird:     {FUNC_LEITOR_BIBLIA_C, FUNC_TITULAR_C, FUNC_AJUDANTE_C},
+}
+
+// functionGroups liga cada fila combinada às colunas da planilha de onde saem
+// os designados; a designação é registrada na coluna da pessoa escolhida.
+var functionGroups = map[string][]string{
+	FUNC_TITULAR_A:  {FUNC_TITULAR_A_HOMEM, FUNC_TITULAR_A_MULHER},
+	FUNC_AJUDANTE_A: {FUNC_AJUDANTE_A_HOMEM, FUNC_AJUDANTE_A_MULHER},
+	FUNC_TITULAR_B:  {FUNC_TITULAR_B_HOMEM, FUNC_TITULAR_B_MULHER},
+	FUNC_AJUDANTE_B: {FUNC_AJUDANTE_B_HOMEM, FUNC_AJUDANTE_B_MULHER},
+	FUNC_TITULAR_C:  {FUNC_TITULAR_C_HOMEM, FUNC_TITULAR_C_MULHER},
+	FUNC_AJUDANTE_C: {FUNC_AJUDANTE_C_HOMEM, FUNC_AJUDANTE_C_MULHER},
+}
+
+// counselorsByHall são os conselheiros das salas auxiliares.
+var counselorsByHall = map[string]string{
+	store.HallAuxiliary: FUNC_CONSELHEIRO,
+	store.HallThird:     FUNC_CONSELHEIRO_C,
+}
+
+// slot é uma vaga da programação: a função da planilha de onde sai o designado
+// e como a designação é registrada no histórico.
+type slot struct {
+	week    int
+	meeting string
+	// date é o dia da reunião, ou o último dia da semana enquanto o dia da
+	// congregação não for conhecido; weekStart e weekEnd são a semana inteira.
+	date      time.Time
+	weekStart time.Time
+	weekEnd   time.Time
+	function  string
+	part      string
+	role      string
+	hall      string
+	// rule é a chave da vaga na tabela eligibility.
+	rule      string
+	exclusive bool
+	// pinned indica que a pessoa da vaga foi fixada antes da geração.
+	pinned bool
+	// avoid é quem ocupava a vaga antes de ela ser refeita e não pode voltar.
+	avoid string
+	// distinctFrom é o índice da vaga cuja pessoa não pode se repetir nesta
+	// (titular e ajudante, leitores das duas salas, as duas orações); -1 se não houver.
+	distinctFrom int
+}
+
+type Options struct {
+	Weights Weights
+	// Solver otimiza o período inteiro depois da escolha semana a semana.
+	Solver bool
+	// SolverIterations é o número de passos do otimizador; zero vale
+	// DefaultSolverIterations.
+	SolverIterations int
+	// Rand é o gerador da geração; com a mesma semente a escala se repete.
+	Rand *rand.Rand
+	// Strict faz a geração falhar com *ConflictError, sem gravar histórico,
+	// se a escala tiver qualquer conflito.
+	Strict bool
+	// Classrooms é o número de salas com partes de estudante; zero vale
+	// DefaultClassrooms.
+	Classrooms int
+	// Pins são as designações fixadas; a geração preenche o resto em volta
+	// delas.
+	Pins []Pin
+	// replaced são as vagas refeitas por Reassign, com quem as ocupava.
+	replaced []Pin
+}
+
+// Halls devolve as salas usadas com o número de salas dado.
+func Halls(classrooms int) []string {
+	if classrooms <= 0 {
+		classrooms = DefaultClassrooms
+	}
+	if classrooms > MaxClassrooms {
+		classrooms = MaxClassrooms
+	}
+	return store.Halls[:classrooms]
+}
+
+type Result struct {
+	Meetings  []parser.MeetingData
+	Decisions []Decision
+	Conflicts []Conflict
+	// Slots traz a escala vaga a vaga, na ordem em que as vagas são preenchidas.
+	Slots []SlotAssignment
+}
+
+func AssignToMeetings(meetings []parser.MeetingData, pool map[string][]Designated, repo store.Repository, f *excelize.File, period string, opts Options) (*Result, error) {
 	if len(meetings) == 0 {
 		return nil, fmt.Errorf("meeting list is empty")
 	}
@@ -40,163 +158,362 @@ func AssignToMeetings(meetings []parser.MeetingData, pool map[string][]Designate
 		return nil, fmt.Errorf("designation pool is empty")
 	}
 
-	for i, meeting := range meetings {
-		used := map[string]bool{}
-		designated := make(map[string]string)
-		date := meeting.MeetingDate
+	h, err := newHistory(repo, f, period)
+	if err != nil {
+		return nil, err
+	}
+	h.weights = opts.Weights
+
+	absences, err := repo.Unavailabilities()
+	if err != nil {
+		return nil, err
+	}
+	h.absences = newAvailability(absences)
 
-		assignTreasures(meeting, designated, pool, used, f, date, true)
-		assignMinistry(meeting, designated, pool, used, f, date, true)
-		assignChristians(meeting, designated, pool, used, f, date, true)
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(0))
+	}
+	pairings, err := repo.Pairings()
+	if err != nil {
+		return nil, err
+	}
+	groupPool(pool, func(list []Designated) { shuffleDesignated(list, rng) })
+	h.profiles = newProfiles(pool, pairings)
 
-		assignFunction(FUNC_PRESIDENTE, designated, pool, used, f, date, true)
-		assignFunction(FUNC_CONSELHEIRO, designated, pool, used, f, date, true)
+	plan := planMeetings(meetings, Halls(opts.Classrooms))
+	names := make([]string, len(plan))
+	if err := applyPins(plan, names, opts.Pins); err != nil {
+		return nil, err
+	}
+	for _, pin := range opts.replaced {
+		if idx := findSlot(plan, pin); idx != -1 {
+			plan[idx].avoid = pin.Name
+		}
+	}
+	for i := range plan {
+		if plan[i].pinned {
+			h.pin(plan, names, i, pool)
+			continue
+		}
+		names[i] = h.pick(plan, names, i, pool)
+	}
 
-		initPrayer := pickUniqueExcluding(FUNC_ORACAO, pool, f, date, used, "", false)
-		designated[FUNC_ORACAO] = initPrayer
-		_ = updateDesignationDate(f, FUNC_ORACAO, initPrayer, date)
+	if opts.Solver {
+		names = solve(plan, names, pool, h, opts)
+		h.replay(plan, names, pool)
+	}
+
+	columns, err := sheetFunctions(f)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := collectConflicts(plan, names, h.absences, h.profiles, columns)
+	conflicts = append(conflicts, pinConflicts(plan, names, pool)...)
+	if opts.Strict && len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
 
-		finalPrayer := pickUniqueExcluding(FUNC_ORACAO, pool, f, date, used, initPrayer, false)
-		designated[FUNC_ORACAO_FINAL] = finalPrayer
-		_ = updateDesignationDate(f, FUNC_ORACAO_FINAL, finalPrayer, date)
+	for i := range meetings {
+		meetings[i].Designated = designatedFor(plan, names, i)
+	}
 
-		meetings[i].Designated = designated
+	if err := h.flush(repo); err != nil {
+		return nil, fmt.Errorf("failed to store assignment history: %w", err)
 	}
-	return meetings, nil
+	return &Result{
+		Meetings:  meetings,
+		Decisions: h.decisions,
+		Conflicts: conflicts,
+		Slots:     slotAssignments(plan, names),
+	}, nil
 }
 
-func assignFunction(function string, dest map[string]string, pool map[string][]Designated, used map[string]bool, f *excelize.File, date string, exclusive bool) {
-	dest[function] = pickUniqueAndRotate(function, pool, f, date, used, exclusive)
+// planner monta as vagas de todas as semanas, na ordem em que são preenchidas.
+type planner struct {
+	slots     []slot
+	halls     []string
+	week      int
+	meeting   string
+	date      time.Time
+	weekStart time.Time
+	weekEnd   time.Time
 }
 
-func assignTreasures(m parser.MeetingData, dest map[string]string, pool map[string][]Designated, used map[string]bool, f *excelize.File, date string, exclusive bool) {
-	for _, key := range getSortedKeys(m.TreasuresFromGodsWord) {
-		text := strings.ToLower(m.TreasuresFromGodsWord[key])
+func planMeetings(meetings []parser.MeetingData, halls []string) []slot {
+	p := &planner{halls: halls}
+	for i, meeting := range meetings {
+		if !meeting.Type.HasMeeting() {
+			continue
+		}
+		p.week = i
+		p.meeting = meeting.MeetingDate
+		p.date = meeting.Date
+		if p.date.IsZero() {
+			p.date = meeting.EndDate
+		}
+		p.weekStart, p.weekEnd = meeting.StartDate, meeting.EndDate
 
-		switch {
-		case strings.Contains(text, "leitura da bíblia"):
-			assignedA := pickUniqueExcluding(FUNC_LEITOR_BIBLIA_A, pool, f, date, used, "", exclusive)
-			assignedB := pickUniqueExcluding(FUNC_LEITOR_BIBLIA_B, pool, f, date, used, assignedA, exclusive)
-			dest[key+".A"] = assignedA
-			dest[key+".B"] = assignedB
+		planTreasures(meeting, p)
+		planMinistry(meeting, p)
+		planChristians(meeting, p)
 
-		case strings.Contains(text, "joias espirituais"):
-			dest[key] = pickUniqueAndRotate(FUNC_JOIAS, pool, f, date, used, exclusive)
+		p.add(FUNC_PRESIDENTE, FUNC_PRESIDENTE, store.RoleHolder, "", ruleChairman, true, -1)
+		for _, hall := range p.halls[1:] {
+			counselor := counselorsByHall[hall]
+			p.add(counselor, counselor, store.RoleHolder, hall, ruleCounselor, true, -1)
+		}
 
-		default:
-			dest[key] = pickUniqueAndRotate(FUNC_DISCURSO_TESOUROS, pool, f, date, used, exclusive)
+		initPrayer := p.add(FUNC_ORACAO, FUNC_ORACAO, store.RoleHolder, "", rulePrayer, false, -1)
+		p.add(FUNC_ORACAO, FUNC_ORACAO_FINAL, store.RoleHolder, "", rulePrayer, false, initPrayer)
+	}
+	return p.slots
+}
+
+func (p *planner) add(function, part, role, hall, rule string, exclusive bool, distinctFrom int) int {
+	p.slots = append(p.slots, slot{
+		week:         p.week,
+		meeting:      p.meeting,
+		date:         p.date,
+		weekStart:    p.weekStart,
+		weekEnd:      p.weekEnd,
+		function:     function,
+		part:         part,
+		role:         role,
+		hall:         hall,
+		rule:         rule,
+		exclusive:    exclusive,
+		distinctFrom: distinctFrom,
+	})
+	return len(p.slots) - 1
+}
+
+func (p *planner) addPair(part, hall, rule, holderFunction, helperFunction string) {
+	holder := p.add(holderFunction, part, store.RoleHolder, hall, rule, true, -1)
+	p.add(helperFunction, part, store.RoleAssistant, hall, ruleAssistant, true, holder)
+}
+
+// addPerHall cria uma vaga da parte em cada sala, com a chave "parte.sala". A
+// exclusividade na semana já impede a mesma pessoa em duas salas; distinctFrom
+// aponta para a sala principal só para o relatório de conflitos.
+func (p *planner) addPerHall(key, rule string, function func(hall string) string) {
+	first := -1
+	for _, hall := range p.halls {
+		idx := p.add(function(hall), key+"."+hall, store.RoleHolder, hall, rule, true, first)
+		if first == -1 {
+			first = idx
 		}
 	}
 }
 
-func assignMinistry(meeting parser.MeetingData, dest map[string]string, pool map[string][]Designated, used map[string]bool, f *excelize.File, date string, exclusive bool) {
-	keys := getSortedKeys(meeting.ApplyYourselfToTheFieldMinistry)
-	total := len(keys)
-	maleSlots := 1
-	femaleSlots := total - maleSlots
+func planTreasures(m parser.MeetingData, p *planner) {
+	for _, part := range m.PartsIn(parser.SectionTreasures) {
+		key, rule := part.Key(), string(part.Kind)
 
-	discourseKeys := []string{}
-	nonDiscourseKeys := []string{}
+		switch part.Kind {
+		case parser.KindBibleReading:
+			p.addPerHall(key, rule, func(hall string) string { return functionsByHall[hall].reader })
 
-	for _, key := range keys {
-		text := strings.ToLower(meeting.ApplyYourselfToTheFieldMinistry[key])
-		switch {
-		case strings.Contains(text, "discurso"):
-			discourseKeys = append(discourseKeys, key)
+		case parser.KindSpiritualGems:
+			p.add(FUNC_JOIAS, key, store.RoleHolder, "", rule, true, -1)
 
 		default:
-			nonDiscourseKeys = append(nonDiscourseKeys, key)
+			p.add(FUNC_DISCURSO_TESOUROS, key, store.RoleHolder, "", rule, true, -1)
 		}
 	}
+}
 
-	for _, key := range discourseKeys {
-		assignedA := pickUniqueExcluding(FUNC_DISCURSO_MINISTERIO, pool, f, date, used, "", exclusive)
-		assignedB := pickUniqueExcluding(FUNC_DISCURSO_MINISTERIO, pool, f, date, used, assignedA, exclusive)
-		dest[key+".A"] = assignedA
-		dest[key+".B"] = assignedB
-	}
-
-	maleSlots -= len(discourseKeys)
-	if maleSlots < 0 {
-		maleSlots = 0
-	}
-	femaleSlots = total - len(discourseKeys) - maleSlots
-
-	for i, key := range nonDiscourseKeys {
-		if i < femaleSlots {
-			holderA := pickUniqueExcluding(FUNC_TITULAR_A_MULHER, pool, f, date, used, "", exclusive)
-			helperA := pickUniqueExcluding(FUNC_AJUDANTE_A_MULHER, pool, f, date, used, holderA, exclusive)
-			holderB := pickUniqueExcluding(FUNC_TITULAR_B_MULHER, pool, f, date, used, "", exclusive)
-			helperB := pickUniqueExcluding(FUNC_AJUDANTE_B_MULHER, pool, f, date, used, holderB, exclusive)
-			dest[key+".A"] = fmt.Sprintf("%s/%s", holderA, helperA)
-			dest[key+".B"] = fmt.Sprintf("%s/%s", holderB, helperB)
-		} else {
-			holderA := pickUniqueExcluding(FUNC_TITULAR_A_HOMEM, pool, f, date, used, "", exclusive)
-			helperA := pickUniqueExcluding(FUNC_AJUDANTE_A_HOMEM, pool, f, date, used, holderA, exclusive)
-			holderB := pickUniqueExcluding(FUNC_TITULAR_B_HOMEM, pool, f, date, used, "", exclusive)
-			helperB := pickUniqueExcluding(FUNC_AJUDANTE_B_HOMEM, pool, f, date, used, holderB, exclusive)
-			dest[key+".A"] = fmt.Sprintf("%s/%s", holderA, helperA)
-			dest[key+".B"] = fmt.Sprintf("%s/%s", holderB, helperB)
+// planMinistry cria as vagas das partes de estudante em cada sala. Quem pode
+// receber cada parte sai da tabela eligibility: discursos ficam com irmãos e
+// demonstrações tiram candidatos das colunas de irmãos e de irmãs.
+func planMinistry(meeting parser.MeetingData, p *planner) {
+	for _, part := range meeting.PartsIn(parser.SectionMinistry) {
+		key, rule := part.Key(), string(part.Kind)
+
+		switch part.Kind {
+		case parser.KindStudentTalk:
+			p.addPerHall(key, rule, func(string) string { return FUNC_DISCURSO_MINISTERIO })
+
+		default:
+			for _, hall := range p.halls {
+				functions := functionsByHall[hall]
+				p.addPair(key+"."+hall, hall, rule, functions.holder, functions.helper)
+			}
 		}
 	}
 }
 
-func assignChristians(m parser.MeetingData, dest map[string]string, pool map[string][]Designated, used map[string]bool, f *excelize.File, date string, exclusive bool) {
-	for _, key := range getSortedKeys(m.LivingAsChristians) {
-		text := strings.ToLower(m.LivingAsChristians[key])
+func planChristians(m parser.MeetingData, p *planner) {
+	for _, part := range m.PartsIn(parser.SectionChristian) {
+		key := part.Key()
 
-		switch {
-		case strings.Contains(text, "estudo bíblico de congregação"):
-			leader := pickUniqueAndRotate(FUNC_ESTUDO_BIBLICO, pool, f, date, used, exclusive)
-			reader := pickUniqueExcluding(FUNC_LEITOR_ESTUDO, pool, f, date, used, leader, exclusive)
-			dest[key] = fmt.Sprintf("%s/%s", leader, reader)
+		switch part.Kind {
+		case parser.KindCongregationStudy:
+			if m.Type == parser.WeekCircuitOverseer {
+				// O superintendente de circuito faz o discurso de serviço no lugar do estudo.
+				continue
+			}
+			leader := p.add(FUNC_ESTUDO_BIBLICO, key, store.RoleHolder, "", string(part.Kind), true, -1)
+			p.add(FUNC_LEITOR_ESTUDO, key, store.RoleAssistant, "", ruleStudyReader, true, leader)
 
 		default:
-			dest[key] = pickUniqueAndRotate(FUNC_DISCURSO_CRISTA, pool, f, date, used, exclusive)
+			p.add(FUNC_DISCURSO_CRISTA, key, store.RoleHolder, "", string(part.Kind), true, -1)
+		}
+	}
+}
 
+// designatedFor monta o mapa de designados de uma semana; vagas com a mesma
+// parte (titular e ajudante, dirigente e leitor) viram "nome/nome".
+func designatedFor(plan []slot, names []string, week int) map[string]string {
+	designated := make(map[string]string)
+	for i, s := range plan {
+		if s.week != week {
+			continue
 		}
+		if current, ok := designated[s.part]; ok {
+			designated[s.part] = fmt.Sprintf("%s/%s", current, names[i])
+			continue
+		}
+		designated[s.part] = names[i]
 	}
+	return designated
 }
 
-func pickUniqueAndRotate(role string, pool map[string][]Designated, f *excelize.File, meeting string, used map[string]bool, exclusive bool) string {
-	return pickUniqueExcluding(role, pool, f, meeting, used, "", exclusive)
+// columnFor devolve a coluna da planilha de onde name entrou na fila.
+func columnFor(list []Designated, name string) string {
+	for _, designated := range list {
+		if designated.Name == name {
+			return designated.Function
+		}
+	}
+	return ""
 }
 
-func pickUniqueExcluding(role string, pool map[string][]Designated, f *excelize.File, meeting string, used map[string]bool, exclude string, exclusive bool) string {
-	list := pool[role]
-	for i := 0; i < len(list); i++ {
-		name := list[i].Name
-		if (!exclusive || !used[name]) && name != exclude {
-			pool[role] = append(list[i+1:], list[:i+1]...)
-			if exclusive {
-				used[name] = true
+// evaluate pontua os candidatos da vaga que estão disponíveis na semana e
+// respeitam a exclusividade, o par e as vagas fixadas, devolvendo também a
+// posição do melhor na fila da função (-1 se nenhum).
+func (h *history) evaluate(plan []slot, names []string, idx int, pool map[string][]Designated) (Decision, int) {
+	s := plan[idx]
+	decision := Decision{Week: s.meeting, Part: s.part, Function: s.function, Role: s.role, Hall: s.hall}
+
+	exclude := ""
+	if s.distinctFrom >= 0 {
+		exclude = names[s.distinctFrom]
+	}
+	used := pinnedExclusions(plan, names, idx)
+	if s.exclusive {
+		for j := 0; j < idx; j++ {
+			if plan[j].week == s.week && plan[j].exclusive {
+				used[names[j]] = true
 			}
-			_ = updateDesignationDate(f, role, name, meeting)
-			return name
 		}
 	}
-	if len(list) > 0 {
-		name := list[0].Name
-		pool[role] = append(list[1:], list[0])
-		if exclusive {
-			used[name] = true
+
+	chosen := -1
+	var best float64
+	for i, designated := range pool[s.function] {
+		name := designated.Name
+		if used[name] || name == exclude || name == s.avoid || !h.absences.available(name, s) ||
+			!h.profiles.allows(plan, names, idx, name) || !h.profiles.pinnedPartnerAllows(plan, names, idx, name) {
+			continue
+		}
+		sc := h.weights.score(h, name, s, partnerOf(plan, names, idx))
+		decision.Candidates = append(decision.Candidates, sc)
+		if chosen == -1 || sc.Total > best {
+			chosen, best = i, sc.Total
 		}
-		_ = updateDesignationDate(f, role, name, meeting)
-		return name
 	}
-	return ""
+	return decision, chosen
+}
+
+// pick escolhe a pessoa de maior pontuação para a vaga. Se ninguém estiver
+// livre, repete o primeiro da fila que está disponível e pode receber a parte,
+// depois o primeiro disponível, ou o primeiro da fila se todos estiverem
+// indisponíveis. Quem saiu de uma vaga refeita nunca volta para ela e um par
+// proibido nunca é formado; sem outra pessoa, a vaga fica vazia. A fila da
+// função é rotacionada para que o escolhido vá para o fim, desempatando as
+// próximas escolhas.
+func (h *history) pick(plan []slot, names []string, idx int, pool map[string][]Designated) string {
+	s := plan[idx]
+	list := pool[s.function]
+	if len(list) == 0 {
+		return ""
+	}
+
+	decision, chosen := h.evaluate(plan, names, idx, pool)
+	if chosen == -1 {
+		allowed := func(name string) bool {
+			return name != s.avoid && !h.profiles.forbiddenPair(plan, names, idx, name)
+		}
+		chosen = forcedChoice(list, func(name string) bool {
+			return allowed(name) && h.absences.available(name, s) && h.profiles.allows(plan, names, idx, name)
+		})
+		if chosen == -1 {
+			chosen = forcedChoice(list, func(name string) bool { return allowed(name) && h.absences.available(name, s) })
+		}
+		if chosen == -1 {
+			chosen = forcedChoice(list, allowed)
+		}
+		decision.Forced = true
+		if chosen == -1 {
+			h.decisions = append(h.decisions, decision)
+			return ""
+		}
+		decision.Candidates = append(decision.Candidates, h.weights.score(h, list[chosen].Name, s, partnerOf(plan, names, idx)))
+	}
+
+	name := list[chosen].Name
+	rotated := make([]Designated, 0, len(list))
+	rotated = append(rotated, list[:chosen]...)
+	rotated = append(rotated, list[chosen+1:]...)
+	pool[s.function] = append(rotated, list[chosen])
+
+	sortScores(decision.Candidates)
+	decision.Chosen = name
+	h.decisions = append(h.decisions, decision)
+	h.record(s, name, list[chosen].Function)
+	return name
+}
+
+// forcedChoice devolve a posição do primeiro da fila que passa em ok, ou -1.
+func forcedChoice(list []Designated, ok func(name string) bool) int {
+	for i, designated := range list {
+		if ok(designated.Name) {
+			return i
+		}
+	}
+	return -1
 }
 
-func getSortedKeys(m map[string]string) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+// replay refaz o histórico e as decisões da geração a partir dos nomes finais,
+// para que a pontuação explique o resultado do solver.
+func (h *history) replay(plan []slot, names []string, pool map[string][]Designated) {
+	h.reset()
+	for i, s := range plan {
+		if s.pinned {
+			h.pin(plan, names, i, pool)
+			continue
+		}
+		decision, _ := h.evaluate(plan, names, i, pool)
+		decision.Forced = true
+		for _, candidate := range decision.Candidates {
+			if candidate.Name == names[i] {
+				decision.Forced = false
+			}
+		}
+		if decision.Forced && names[i] != "" {
+			decision.Candidates = append(decision.Candidates, h.weights.score(h, names[i], s, partnerOf(plan, names, i)))
+		}
+		sortScores(decision.Candidates)
+		decision.Chosen = names[i]
+		h.decisions = append(h.decisions, decision)
+		if names[i] != "" {
+			h.record(s, names[i], columnFor(pool[s.function], names[i]))
+		}
 	}
-	sort.Strings(keys)
-	return keys
 }
 
-func updateDesignationDate(f *excelize.File, role string, name string, meetingDate string) error {
+func updateDesignationDate(f *excelize.File, role string, name string, date time.Time) error {
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
 		return err
@@ -222,9 +539,8 @@ func updateDesignationDate(f *excelize.File, role string, name string, meetingDa
 		return fmt.Errorf("column for %s not found", role)
 	}
 
-	date := extractLastDateFromMeeting(meetingDate)
-	if date == "" {
-		return fmt.Errorf("invalid date for meeting: %s", meetingDate)
+	if date.IsZero() {
+		return fmt.Errorf("missing date for %s designation", role)
 	}
 
 	for i, row := range rows {
@@ -237,29 +553,9 @@ func updateDesignationDate(f *excelize.File, role string, name string, meetingDa
 		if strings.TrimSpace(row[publicadoresIdx]) == name {
 			colName, _ := excelize.ColumnNumberToName(roleColIdx + 2)
 			cell := fmt.Sprintf("%s%d", colName, i+1)
-			return f.SetCellValue(sheetName, cell, date)
+			return f.SetCellValue(sheetName, cell, date.Format(dateLayout))
 		}
 	}
 
 	return fmt.Errorf("designated %s not found", name)
 }
-
-func extractLastDateFromMeeting(meeting string) string {
-	re := regexp.MustCompile(`(?i)a\s+(\d{1,2})\s+de\s+([a-zç]+)`)
-	match := re.FindStringSubmatch(meeting)
-	if len(match) < 3 {
-		return ""
-	}
-	day := match[1]
-	month := strings.ToLower(match[2])
-	months := map[string]string{
-		"janeiro": "01", "fevereiro": "02", "março": "03", "marco": "03", "abril": "04",
-		"maio": "05", "junho": "06", "julho": "07", "agosto": "08", "setembro": "09",
-		"outubro": "10", "novembro": "11", "dezembro": "12",
-	}
-	monthNum, ok := months[month]
-	if !ok {
-		return ""
-	}
-	return fmt.Sprintf("%02s/%s/2025", day, monthNum)
-}
@@ -0,0 +1,108 @@
+package assigner
+
+import (
+	"math/rand"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// seedSheet monta uma planilha nova a cada geração, já que a geração grava nela
+// as datas de última designação.
+func seedSheet(t *testing.T) *excelize.File {
+	return testSheet(t, testNames(30), func(row, function int) bool { return (row+function)%3 != 0 })
+}
+
+func TestAssignToMeetingsSameSeed(t *testing.T) {
+	for _, solver := range []bool{false, true} {
+		opts := Options{Solver: solver, SolverIterations: 2000}
+		first := generate(t, seedSheet(t), 3, 42, opts)
+		second := generate(t, seedSheet(t), 3, 42, opts)
+		if !reflect.DeepEqual(first.Slots, second.Slots) {
+			t.Errorf("solver=%v: seed 42 gave two different schedules", solver)
+		}
+	}
+}
+
+func TestAssignToMeetingsDifferentSeed(t *testing.T) {
+	for _, solver := range []bool{false, true} {
+		opts := Options{Solver: solver, SolverIterations: 2000}
+		first := generate(t, seedSheet(t), 3, 1, opts)
+		changed := false
+		for seed := int64(2); seed < 10 && !changed; seed++ {
+			changed = !reflect.DeepEqual(first.Slots, generate(t, seedSheet(t), 3, seed, opts).Slots)
+		}
+		if !changed {
+			t.Errorf("solver=%v: seeds 1 to 9 all gave the same schedule", solver)
+		}
+	}
+}
+
+func TestPlanMeetingsDate(t *testing.T) {
+	meetings := testMeetings(t, 2)
+	parser.SetMeetingDay(meetings[:1], time.Wednesday)
+
+	wednesday := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	sunday := time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)
+	for _, s := range planMeetings(meetings, Halls(0)) {
+		want := wednesday
+		if s.week == 1 {
+			want = sunday
+		}
+		if !s.date.Equal(want) {
+			t.Fatalf("week %d slot %s dated %s, want %s", s.week+1, s.part, s.date.Format("2006-01-02"), want.Format("2006-01-02"))
+		}
+	}
+}
+
+// TestAvailableWholeWeek confere que, com o dia da reunião conhecido, a
+// indisponibilidade continua valendo para a semana inteira.
+func TestAvailableWholeWeek(t *testing.T) {
+	meetings := testMeetings(t, 1)
+	parser.SetMeetingDay(meetings, time.Wednesday)
+	s := planMeetings(meetings, Halls(0))[0]
+
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     bool
+	}{
+		{"before the week", day(1), day(2), true},
+		{"monday", day(3), day(3), false},
+		{"after the meeting day", day(7), day(9), false},
+		{"after the week", day(10), day(12), true},
+	}
+	for _, tt := range tests {
+		a := newAvailability([]store.Unavailability{{Name: "Ana", From: tt.from, To: tt.to}})
+		if got := a.available("Ana", s); got != tt.want {
+			t.Errorf("%s: available = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryUsesMeetingDay(t *testing.T) {
+	f := seedSheet(t)
+	repo, pool := testRepository(t, f, 1)
+	meetings := testMeetings(t, 1)
+	parser.SetMeetingDay(meetings, time.Wednesday)
+	opts := Options{Weights: DefaultWeights, Rand: rand.New(rand.NewSource(1))}
+	if _, err := AssignToMeetings(meetings, pool, repo, f, testPeriod, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := repo.Assignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wednesday := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	for _, a := range history {
+		if a.Period == testPeriod && !a.Date.Equal(wednesday) {
+			t.Fatalf("%s in %s dated %s, want 2025-03-05", a.Name, a.Part, a.Date.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package assigner
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"midweek-project/internal/parser"
+)
+
+const weekTypesSheet = "Semanas"
+
+// ReadWeekTypes lê a aba opcional "Semanas" da planilha de designados, com as
+// colunas Semana (qualquer data da semana) e Tipo (normal, visita, assembleia
+// ou celebração).
+func ReadWeekTypes(f *excelize.File) ([]parser.WeekOverride, error) {
+	if idx, err := f.GetSheetIndex(weekTypesSheet); err != nil || idx == -1 {
+		return nil, nil
+	}
+
+	rows, err := f.GetRows(weekTypesSheet)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tab %s: %v", weekTypesSheet, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	dateIdx, typeIdx := -1, -1
+	for idx, header := range rows[0] {
+		switch normalizeHeader(header) {
+		case "semana", "data":
+			dateIdx = idx
+		case "tipo":
+			typeIdx = idx
+		}
+	}
+	if dateIdx == -1 || typeIdx == -1 {
+		return nil, fmt.Errorf("tab %s must have 'Semana' and 'Tipo' columns", weekTypesSheet)
+	}
+
+	var overrides []parser.WeekOverride
+	for rowIdx, row := range rows[1:] {
+		dateValue, typeValue := cellAt(row, dateIdx), cellAt(row, typeIdx)
+		if dateValue == "" && typeValue == "" {
+			continue
+		}
+		date, err := parseSheetDate(dateValue)
+		if err != nil {
+			return nil, fmt.Errorf("tab %s, row %d: invalid date: %w", weekTypesSheet, rowIdx+2, err)
+		}
+		week, err := parser.ParseWeekType(typeValue)
+		if err != nil {
+			return nil, fmt.Errorf("tab %s, row %d: %w", weekTypesSheet, rowIdx+2, err)
+		}
+		overrides = append(overrides, parser.WeekOverride{Date: date, Type: week})
+	}
+	return overrides, nil
+}
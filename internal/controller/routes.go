@@ -7,8 +7,28 @@ import (
 
 func RegisterRoutes(e *echo.Echo) {
 	e.POST("/generate-schedule", handler.GenerateSchedule)
+	e.GET("/schedules", handler.ListSchedulePeriods)
+	e.GET("/schedules/:period", handler.GetSchedule)
+	e.GET("/schedules/:period/versions", handler.ListScheduleVersions)
+	e.GET("/schedules/:period/versions/:version", handler.GetScheduleVersion)
+	e.GET("/schedules/:period/versions/:version/download", handler.DownloadScheduleVersion)
+	e.POST("/schedules/:period/versions/:version/publish", handler.PublishScheduleVersion)
+	e.POST("/schedules/:period/regenerate", handler.RegenerateSchedule)
+	e.POST("/schedules/:period/swap", handler.SwapAssignments)
+	e.POST("/schedules/:period/substitute", handler.SubstituteAssignment)
+	e.GET("/schedules/:period/audit", handler.ListAudit)
 
 	e.POST("/upload-zip", handler.HandleUploadZip)
 	e.GET("/list-zip-files", handler.ListZipFiles)
 	e.DELETE("/delete-zip-file", handler.DeleteZipFile)
+
+	e.GET("/congregations", handler.ListCongregations)
+	e.POST("/congregations", handler.CreateCongregation)
+	e.GET("/congregations/:id", handler.GetCongregation)
+	e.PUT("/congregations/:id", handler.UpdateCongregation)
+	e.DELETE("/congregations/:id", handler.DeleteCongregation)
+
+	e.GET("/unavailabilities", handler.ListUnavailabilities)
+	e.POST("/unavailabilities", handler.CreateUnavailability)
+	e.DELETE("/unavailabilities/:id", handler.DeleteUnavailability)
 }
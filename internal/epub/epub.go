@@ -0,0 +1,250 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	mimetypeEntry = "mimetype"
+	containerPath = "META-INF/container.xml"
+	epubMimetype  = "application/epub+zip"
+)
+
+var (
+	blockElements = map[string]bool{
+		"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"section": true, "article": true, "header": true, "footer": true, "figcaption": true,
+		"dt": true, "dd": true, "blockquote": true, "ul": true, "ol": true, "table": true,
+	}
+	skippedElements = map[string]bool{
+		"head": true, "script": true, "style": true, "noscript": true,
+	}
+
+	reNumberedHeading = regexp.MustCompile(`^\d{1,3}[.\x{FF0E}]?\s+\S`)
+	reDurationPrefix  = regexp.MustCompile(`(?i)^\(\s*\d{1,3}\s*(?:minutos|minutes|mins?)\.?\s*\)`)
+	reSpaces          = regexp.MustCompile(`[ \t\r\n]+`)
+)
+
+type Chapter struct {
+	Name string
+	Text string
+}
+
+type container struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type packageDocument struct {
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// IsEPUB verifica a entrada "mimetype" que todo pacote EPUB traz na raiz do zip.
+func IsEPUB(zipPath string) bool {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return false
+	}
+
+	defer func(reader *zip.ReadCloser) {
+		_ = reader.Close()
+	}(reader)
+
+	for _, file := range reader.File {
+		if file.Name != mimetypeEntry {
+			continue
+		}
+		content, err := readEntry(file)
+		if err != nil {
+			return false
+		}
+		return strings.TrimSpace(string(content)) == epubMimetype
+	}
+	return false
+}
+
+// ReadChapters devolve o texto de cada documento XHTML do EPUB, na ordem do spine,
+// com um bloco por linha para que o parser trate como o texto convertido do RTF.
+func ReadChapters(zipPath string) ([]Chapter, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open epub: %w", err)
+	}
+
+	defer func(reader *zip.ReadCloser) {
+		_ = reader.Close()
+	}(reader)
+
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	opfPath, err := findPackagePath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg packageDocument
+	if err := decodeEntry(files, opfPath, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to read package document: %w", err)
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html" {
+			hrefs[item.ID] = path.Join(path.Dir(opfPath), item.Href)
+		}
+	}
+
+	var chapters []Chapter
+	for _, itemRef := range pkg.Spine {
+		href, ok := hrefs[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		file, ok := files[href]
+		if !ok {
+			return nil, fmt.Errorf("spine item %s not found in epub", href)
+		}
+		content, err := readEntry(file)
+		if err != nil {
+			return nil, err
+		}
+		text, err := xhtmlToText(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", href, err)
+		}
+		chapters = append(chapters, Chapter{Name: href, Text: text})
+	}
+
+	return chapters, nil
+}
+
+func findPackagePath(files map[string]*zip.File) (string, error) {
+	var c container
+	if err := decodeEntry(files, containerPath, &c); err != nil {
+		return "", fmt.Errorf("failed to read container: %w", err)
+	}
+	if len(c.Rootfiles) == 0 || c.Rootfiles[0].FullPath == "" {
+		return "", fmt.Errorf("epub container without rootfile")
+	}
+	return c.Rootfiles[0].FullPath, nil
+}
+
+func decodeEntry(files map[string]*zip.File, name string, v any) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s not found", name)
+	}
+	content, err := readEntry(file)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(content, v)
+}
+
+func readEntry(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(rc io.ReadCloser) {
+		_ = rc.Close()
+	}(rc)
+
+	return io.ReadAll(rc)
+}
+
+func xhtmlToText(content []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var lines []string
+	var current strings.Builder
+	skipDepth := 0
+
+	endLine := func() {
+		line := strings.TrimSpace(reSpaces.ReplaceAllString(current.String(), " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+		current.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if skippedElements[name] {
+				skipDepth++
+			} else if blockElements[name] {
+				endLine()
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if skippedElements[name] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			} else if blockElements[name] {
+				endLine()
+			}
+		case xml.CharData:
+			if skipDepth == 0 {
+				current.Write(t)
+			}
+		}
+	}
+	endLine()
+
+	return strings.Join(joinSplitTopics(lines), "\n"), nil
+}
+
+// joinSplitTopics junta o título numerado de uma parte com a duração que o EPUB
+// coloca no parágrafo seguinte, formando "N. Título (X min)".
+func joinSplitTopics(lines []string) []string {
+	var result []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if reNumberedHeading.MatchString(line) && i+1 < len(lines) {
+			if duration := reDurationPrefix.FindString(lines[i+1]); duration != "" {
+				result = append(result, line+" "+duration)
+				if rest := strings.TrimSpace(strings.TrimPrefix(lines[i+1], duration)); rest != "" {
+					result = append(result, rest)
+				}
+				i++
+				continue
+			}
+		}
+		result = append(result, line)
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package epub
+
+import (
+	"archive/zip"
+	"midweek-project/internal/locale"
+	"midweek-project/internal/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixture = "testdata/mwb.epub"
+
+func TestReadChapters(t *testing.T) {
+	chapters, err := ReadChapters(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A ordem é a do spine, não a do manifest, e o CSS do spine fica de fora.
+	var names []string
+	for _, chapter := range chapters {
+		names = append(names, chapter.Name)
+	}
+	want := []string{"OEBPS/text/cover.xhtml", "OEBPS/text/week1.xhtml", "OEBPS/text/week2.xhtml"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Fatalf("chapters %v, want %v", names, want)
+	}
+
+	for _, chapter := range chapters[1:] {
+		base := strings.TrimSuffix(filepath.Base(chapter.Name), ".xhtml")
+		expected, err := os.ReadFile(filepath.Join("testdata", base+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chapter.Text != strings.TrimSuffix(string(expected), "\n") {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", base, chapter.Text, expected)
+		}
+	}
+}
+
+// TestReadChaptersWeeks confere que só os capítulos de semana passam pelo
+// filtro do parser e viram reuniões com as partes da apostila.
+func TestReadChaptersWeeks(t *testing.T) {
+	chapters, err := ReadChapters(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var weeks []string
+	for _, chapter := range chapters {
+		if parser.IsMeetingContent(chapter.Text) {
+			weeks = append(weeks, chapter.Text)
+		}
+	}
+	if len(weeks) != 2 {
+		t.Fatalf("got %d week chapters, want 2", len(weeks))
+	}
+
+	meetings, err := parser.ParseAllMeetings(weeks, parser.DateReference{Year: 2025, Month: 3}, locale.MustGet(locale.Default))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"2025-03-03", "2025-03-10"} {
+		m := meetings[i]
+		if got := m.StartDate.Format("2006-01-02"); got != want {
+			t.Errorf("week %d starts %s, want %s", i+1, got, want)
+		}
+		if len(m.Parts) != 5 {
+			t.Errorf("week %d has %d parts, want 5", i+1, len(m.Parts))
+		}
+	}
+}
+
+func TestIsEPUB(t *testing.T) {
+	if !IsEPUB(fixture) {
+		t.Errorf("IsEPUB(%s) = false", fixture)
+	}
+
+	path := filepath.Join(t.TempDir(), "rtf.zip")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(out)
+	if _, err := w.Create("01.rtf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+	if IsEPUB(path) {
+		t.Error("IsEPUB of a zip of RTF files = true")
+	}
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"midweek-project/internal/agenda"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/locale"
+	"midweek-project/internal/service"
+	"midweek-project/internal/store"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// O ID vira nome de diretório do store da congregação.
+var reCongregationID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+type congregationRequest struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Classrooms  int      `json:"classrooms"`
+	MeetingDay  string   `json:"meeting_day"`
+	MeetingTime string   `json:"meeting_time"`
+	Locale      string   `json:"locale"`
+	Outputs     []string `json:"outputs"`
+	HallNames   []string `json:"hall_names"`
+}
+
+func ListCongregations(c echo.Context) error {
+	entries, err := service.ListCongregations()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func GetCongregation(c echo.Context) error {
+	congregation, err := service.GetCongregation(c.Param("id"))
+	if errors.Is(err, service.ErrCongregationNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Congregation not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, congregation)
+}
+
+func CreateCongregation(c echo.Context) error {
+	var req congregationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	congregation, err := parseCongregation(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	_, err = service.GetCongregation(congregation.ID)
+	if err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Congregation already exists",
+		})
+	}
+	if !errors.Is(err, service.ErrCongregationNotFound) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := service.SaveCongregation(congregation); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, congregation)
+}
+
+func UpdateCongregation(c echo.Context) error {
+	var req congregationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	req.ID = c.Param("id")
+
+	congregation, err := parseCongregation(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if _, err := service.GetCongregation(congregation.ID); err != nil {
+		return congregationError(c, err)
+	}
+	if err := service.SaveCongregation(congregation); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, congregation)
+}
+
+func DeleteCongregation(c echo.Context) error {
+	if err := service.DeleteCongregation(c.Param("id")); err != nil {
+		return congregationError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// congregationError responde 404 para congregação desconhecida e 500 para o resto.
+func congregationError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrCongregationNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Congregation not found",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// parseCongregation valida o perfil e normaliza dia, horário e idioma para a
+// forma em que são gravados.
+func parseCongregation(req congregationRequest) (store.Congregation, error) {
+	congregation := store.Congregation{
+		ID:         strings.TrimSpace(req.ID),
+		Name:       strings.TrimSpace(req.Name),
+		Classrooms: req.Classrooms,
+	}
+
+	if !reCongregationID.MatchString(congregation.ID) {
+		return store.Congregation{}, fmt.Errorf("id must use only lowercase letters, digits and hyphens")
+	}
+	if congregation.Name == "" {
+		return store.Congregation{}, fmt.Errorf("Missing name")
+	}
+
+	if congregation.Classrooms == 0 {
+		congregation.Classrooms = assigner.DefaultClassrooms
+	}
+	if congregation.Classrooms < assigner.MinClassrooms || congregation.Classrooms > assigner.MaxClassrooms {
+		return store.Congregation{}, fmt.Errorf("classrooms must be between %d and %d", assigner.MinClassrooms, assigner.MaxClassrooms)
+	}
+
+	if req.MeetingDay != "" {
+		day, err := agenda.ParseWeekday(req.MeetingDay)
+		if err != nil {
+			return store.Congregation{}, fmt.Errorf("meeting_day: %w", err)
+		}
+		congregation.MeetingDay = strings.ToLower(day.String())
+	}
+
+	if req.MeetingTime != "" {
+		start, err := agenda.ParseClock(req.MeetingTime)
+		if err != nil {
+			return store.Congregation{}, fmt.Errorf("meeting_time must be a time in the format HH:MM")
+		}
+		congregation.MeetingTime = agenda.FormatClock(start)
+	}
+
+	loc, err := locale.Get(req.Locale)
+	if err != nil {
+		return store.Congregation{}, err
+	}
+	congregation.Locale = loc.Code
+
+	seen := make(map[string]bool)
+	for _, output := range req.Outputs {
+		output = strings.ToLower(strings.TrimSpace(output))
+		if !isOutput(output) {
+			return store.Congregation{}, fmt.Errorf("unknown output %q, expected one of %s", output, strings.Join(store.Outputs, ", "))
+		}
+		if !seen[output] {
+			seen[output] = true
+			congregation.Outputs = append(congregation.Outputs, output)
+		}
+	}
+
+	if len(req.HallNames) > congregation.Classrooms {
+		return store.Congregation{}, fmt.Errorf("hall_names has %d names for %d classrooms", len(req.HallNames), congregation.Classrooms)
+	}
+	for _, name := range req.HallNames {
+		congregation.HallNames = append(congregation.HallNames, strings.TrimSpace(name))
+	}
+
+	return congregation, nil
+}
+
+func isOutput(value string) bool {
+	for _, output := range store.Outputs {
+		if output == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,29 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	"midweek-project/internal/agenda"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/locale"
+	"midweek-project/internal/parser"
 	"midweek-project/internal/service"
+	"midweek-project/internal/store"
+	"midweek-project/internal/writer"
 	"mime/multipart"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatZip  = "zip"
+	formatJSON = "json"
+
+	maxSolverIterations = 1000000
 )
 
 func ListZipFiles(c echo.Context) error {
@@ -62,12 +81,183 @@ func GenerateSchedule(c echo.Context) error {
 		})
 	}
 
-	zipBytes, err := service.ProcessSchedule(src, period)
+	// format=json devolve as reuniões no formato de service.Schedule em vez do zip.
+	format := c.FormValue("format")
+	if format != "" && format != formatZip && format != formatJSON {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "format must be zip or json",
+		})
+	}
+
+	var congregation store.Congregation
+	if id := c.FormValue("congregation"); id != "" {
+		congregation, err = service.GetCongregation(id)
+		if err != nil {
+			return congregationError(c, err)
+		}
+	}
+
+	// O perfil da congregação dá os padrões; os campos do formulário vencem.
+	opts, err := service.NewScheduleOptions(congregation)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
 	}
+	if value := c.FormValue("slips_per_page"); value != "" {
+		slipsPerPage, err := strconv.Atoi(value)
+		if err != nil || (slipsPerPage != writer.SlipsPerPageOne && slipsPerPage != writer.SlipsPerPageFour) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "slips_per_page must be 1 or 4",
+			})
+		}
+		opts.SlipsPerPage = slipsPerPage
+	}
+
+	// Os pesos são lidos numa ordem fixa para que, com mais de um inválido, o
+	// erro seja sempre o do primeiro.
+	weightFields := []struct {
+		name   string
+		weight *float64
+	}{
+		{"weight_role_recency", &opts.Weights.RoleRecency},
+		{"weight_slot_recency", &opts.Weights.SlotRecency},
+		{"weight_any_recency", &opts.Weights.AnyRecency},
+		{"weight_recent_count", &opts.Weights.RecentCount},
+		{"weight_period_count", &opts.Weights.PeriodCount},
+		{"weight_week_load", &opts.Weights.WeekLoad},
+		{"weight_preferred_pair", &opts.Weights.PreferredPair},
+		{"weight_repeat_pair", &opts.Weights.RepeatPair},
+	}
+	for _, field := range weightFields {
+		value := c.FormValue(field.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("%s must be a number", field.name),
+			})
+		}
+		*field.weight = parsed
+	}
+
+	if value := c.FormValue("solver"); value != "" {
+		solver, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "solver must be true or false",
+			})
+		}
+		opts.Solver = solver
+	}
+
+	// O limite do otimizador é em passos, não em tempo, para que a semente
+	// refaça a mesma escala em qualquer máquina.
+	if value := c.FormValue("solver_iterations"); value != "" {
+		iterations, err := strconv.Atoi(value)
+		if err != nil || iterations < 1 || iterations > maxSolverIterations {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("solver_iterations must be between 1 and %d", maxSolverIterations),
+			})
+		}
+		opts.SolverIterations = iterations
+	}
+
+	opts.Seed = time.Now().UnixNano()
+	if value := c.FormValue("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "seed must be an integer",
+			})
+		}
+		opts.Seed = seed
+	}
+
+	if value := c.FormValue("strict"); value != "" {
+		strict, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "strict must be true or false",
+			})
+		}
+		opts.Strict = strict
+	}
+
+	if value := c.FormValue("year"); value != "" {
+		year, err := strconv.Atoi(value)
+		if err != nil || year < 2000 || year > 2100 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "year must be a four-digit year",
+			})
+		}
+		opts.Year = year
+	}
+
+	if value := c.FormValue("meeting_start"); value != "" {
+		start, err := agenda.ParseClock(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "meeting_start must be a time in the format HH:MM",
+			})
+		}
+		opts.Writer.MeetingStart = start
+	}
+
+	if value := c.FormValue("classrooms"); value != "" {
+		classrooms, err := strconv.Atoi(value)
+		if err != nil || classrooms < assigner.MinClassrooms || classrooms > assigner.MaxClassrooms {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("classrooms must be between %d and %d", assigner.MinClassrooms, assigner.MaxClassrooms),
+			})
+		}
+		opts.Classrooms = classrooms
+	}
+
+	if value := c.FormValue("locale"); value != "" {
+		loc, err := locale.Get(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts.Writer.Locale = loc
+	}
+
+	if value := c.FormValue("week_types"); value != "" {
+		weekTypes, err := parseWeekTypes(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts.WeekTypes = weekTypes
+	}
+
+	if value := c.FormValue("pins"); value != "" {
+		pins, err := parsePins(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		opts.Pins = pins
+	}
+
+	if format == formatJSON {
+		schedule, err := service.ProcessScheduleJSON(src, period, opts)
+		if err != nil {
+			return scheduleError(c, err)
+		}
+		return c.JSON(http.StatusOK, schedule)
+	}
+
+	zipBytes, err := service.ProcessSchedule(src, period, opts)
+	if err != nil {
+		return scheduleError(c, err)
+	}
 
 	return c.Blob(http.StatusOK, "application/zip", zipBytes)
 }
@@ -94,3 +284,57 @@ func HandleUploadZip(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "ZIP file uploaded successfully"})
 }
+
+// parseWeekTypes lê o campo week_types, um objeto JSON de data da semana para
+// tipo, por exemplo {"2025-03-10": "circuit_overseer"}.
+func parseWeekTypes(value string) ([]parser.WeekOverride, error) {
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, fmt.Errorf("week_types must be a JSON object of date to week type")
+	}
+
+	dates := make([]string, 0, len(raw))
+	for date := range raw {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	var overrides []parser.WeekOverride
+	for _, value := range dates {
+		date, err := time.Parse(apiDateLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("week_types: %q must be a date in the format YYYY-MM-DD", value)
+		}
+		week, err := parser.ParseWeekType(raw[value])
+		if err != nil {
+			return nil, fmt.Errorf("week_types: %w", err)
+		}
+		overrides = append(overrides, parser.WeekOverride{Date: date, Type: week})
+	}
+	return overrides, nil
+}
+
+// parsePins lê o campo pins, uma lista JSON de designações fixadas, por exemplo
+// [{"week": 3, "part": "1", "name": "João Silva"}]. slot é holder (padrão) ou
+// assistant.
+func parsePins(value string) ([]assigner.Pin, error) {
+	var pins []assigner.Pin
+	if err := json.Unmarshal([]byte(value), &pins); err != nil {
+		return nil, fmt.Errorf("pins must be a JSON list of {week, part, slot, name}")
+	}
+	for i := range pins {
+		pin := &pins[i]
+		pin.Part = assigner.PartKey(strings.TrimSpace(pin.Part))
+		pin.Name = strings.TrimSpace(pin.Name)
+		if pin.Week < 1 {
+			return nil, fmt.Errorf("pin %d: week must be 1 or more", i+1)
+		}
+		if pin.Part == "" || pin.Name == "" {
+			return nil, fmt.Errorf("pin %d: part and name are required", i+1)
+		}
+		if pin.Slot != "" && pin.Slot != store.RoleHolder && pin.Slot != store.RoleAssistant {
+			return nil, fmt.Errorf("pin %d: slot must be %s or %s", i+1, store.RoleHolder, store.RoleAssistant)
+		}
+	}
+	return pins, nil
+}
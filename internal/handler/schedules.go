@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/service"
+	"midweek-project/internal/store"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// slotRequest é a posição de uma vaga: a semana (a partir de 1), o part como no
+// JSON da programação e holder ou assistant.
+type slotRequest struct {
+	Week int    `json:"week"`
+	Part string `json:"part"`
+	Slot string `json:"slot"`
+}
+
+type swapRequest struct {
+	First  slotRequest `json:"first"`
+	Second slotRequest `json:"second"`
+	By     string      `json:"by"`
+	Reason string      `json:"reason"`
+}
+
+type substituteRequest struct {
+	slotRequest
+	Name   string `json:"name"`
+	By     string `json:"by"`
+	Reason string `json:"reason"`
+}
+
+// RegenerateSchedule refaz uma semana, uma parte ou uma vaga da última versão
+// da programação do período e devolve o zip da nova versão.
+func RegenerateSchedule(c echo.Context) error {
+	var req slotRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	target := assigner.Target{Week: req.Week, Part: assigner.PartKey(strings.TrimSpace(req.Part)), Slot: strings.TrimSpace(req.Slot)}
+	if target.Week < 1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "week must be 1 or more",
+		})
+	}
+	if target.Slot != "" && target.Slot != store.RoleHolder && target.Slot != store.RoleAssistant {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "slot must be holder or assistant",
+		})
+	}
+
+	zipBytes, err := service.RegenerateSchedule(c.QueryParam("congregation"), c.Param("period"), target)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "application/zip", zipBytes)
+}
+
+// SwapAssignments troca as pessoas de duas vagas da última versão da
+// programação do período e devolve o zip da nova versão.
+func SwapAssignments(c echo.Context) error {
+	var req swapRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	first, err := parseSlot(req.First)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "first: " + err.Error(),
+		})
+	}
+	second, err := parseSlot(req.Second)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "second: " + err.Error(),
+		})
+	}
+	by := strings.TrimSpace(req.By)
+	if by == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing by",
+		})
+	}
+
+	zipBytes, err := service.SwapAssignments(c.QueryParam("congregation"), c.Param("period"), first, second, by, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "application/zip", zipBytes)
+}
+
+// SubstituteAssignment põe outra pessoa numa vaga da última versão da
+// programação do período e devolve o zip da nova versão.
+func SubstituteAssignment(c echo.Context) error {
+	var req substituteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	target, err := parseSlot(req.slotRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing name",
+		})
+	}
+	by := strings.TrimSpace(req.By)
+	if by == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing by",
+		})
+	}
+
+	zipBytes, err := service.SubstituteAssignment(c.QueryParam("congregation"), c.Param("period"), target, name, by, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "application/zip", zipBytes)
+}
+
+func ListAudit(c echo.Context) error {
+	entries, err := service.ListAudit(c.QueryParam("congregation"), c.Param("period"))
+	if err != nil {
+		return congregationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func ListSchedulePeriods(c echo.Context) error {
+	periods, err := service.ListSchedulePeriods(c.QueryParam("congregation"))
+	if err != nil {
+		return congregationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, periods)
+}
+
+// GetSchedule devolve a programação do período no formato JSON de
+// service.Schedule: a versão do parâmetro version ou, sem ele, a publicada, e
+// na falta dela a mais recente.
+func GetSchedule(c echo.Context) error {
+	version := 0
+	if value := c.QueryParam("version"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "version must be a positive integer",
+			})
+		}
+		version = v
+	}
+
+	schedule, err := service.GetSchedule(c.QueryParam("congregation"), c.Param("period"), version)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
+
+func ListScheduleVersions(c echo.Context) error {
+	versions, err := service.ListScheduleVersions(c.QueryParam("congregation"), c.Param("period"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+func GetScheduleVersion(c echo.Context) error {
+	version, err := parseVersion(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	detail, err := service.GetScheduleVersion(c.QueryParam("congregation"), c.Param("period"), version)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, detail)
+}
+
+// DownloadScheduleVersion devolve o zip entregue quando a versão foi gerada.
+func DownloadScheduleVersion(c echo.Context) error {
+	version, err := parseVersion(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	zipBytes, err := service.DownloadScheduleVersion(c.QueryParam("congregation"), c.Param("period"), version)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.Blob(http.StatusOK, "application/zip", zipBytes)
+}
+
+// PublishScheduleVersion marca a versão como a publicada do período, no lugar
+// da que estava publicada antes.
+func PublishScheduleVersion(c echo.Context) error {
+	version, err := parseVersion(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	summary, err := service.PublishScheduleVersion(c.QueryParam("congregation"), c.Param("period"), version)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+func parseVersion(c echo.Context) (int, error) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		return 0, errors.New("version must be a positive integer")
+	}
+	return version, nil
+}
+
+// parseSlot valida a posição de uma vaga; slot vazio é a vaga de titular.
+func parseSlot(req slotRequest) (assigner.Target, error) {
+	target := assigner.Target{Week: req.Week, Part: assigner.PartKey(strings.TrimSpace(req.Part)), Slot: strings.TrimSpace(req.Slot)}
+	if target.Week < 1 {
+		return target, errors.New("week must be 1 or more")
+	}
+	if target.Part == "" {
+		return target, errors.New("part is required")
+	}
+	if target.Slot != "" && target.Slot != store.RoleHolder && target.Slot != store.RoleAssistant {
+		return target, errors.New("slot must be holder or assistant")
+	}
+	return target, nil
+}
+
+// scheduleError traduz os erros das operações sobre programações guardadas.
+func scheduleError(c echo.Context, err error) error {
+	var conflictErr *assigner.ConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		return c.JSON(http.StatusUnprocessableEntity, map[string]any{
+			"error":     err.Error(),
+			"conflicts": conflictErr.Conflicts,
+		})
+	case errors.Is(err, service.ErrScheduleNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Schedule not found",
+		})
+	case errors.Is(err, parser.ErrUnknownYear):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+			"hint":  "pass the year of the meetings in the year field, e.g. year=2026",
+		})
+	case errors.Is(err, assigner.ErrInvalidTarget), errors.Is(err, assigner.ErrInvalidPin),
+		errors.Is(err, service.ErrInvalidPeriod):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return congregationError(c, err)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/service"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestScheduleErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w 1: week 3 has no holder slot for part %q", assigner.ErrInvalidPin, "99"), http.StatusBadRequest},
+		{assigner.ErrInvalidTarget, http.StatusBadRequest},
+		{service.ErrInvalidPeriod, http.StatusBadRequest},
+		{parser.ErrUnknownYear, http.StatusBadRequest},
+		{service.ErrScheduleNotFound, http.StatusNotFound},
+		{&assigner.ConflictError{}, http.StatusUnprocessableEntity},
+		{errors.New("disk full"), http.StatusInternalServerError},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/generate-schedule", nil), rec)
+		if err := scheduleError(c, tt.err); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != tt.want {
+			t.Errorf("%v: got status %d, want %d", tt.err, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestGenerateScheduleInvalidWeights(t *testing.T) {
+	e := echo.New()
+	// Com dois pesos inválidos, o erro é sempre o do primeiro na ordem fixa.
+	for i := 0; i < 20; i++ {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		file, err := form.CreateFormFile("designates", "designados.xlsx")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte("not read"))
+		form.WriteField("period", "2025-03")
+		form.WriteField("weight_repeat_pair", "many")
+		form.WriteField("weight_role_recency", "few")
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/generate-schedule", &body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		if err := GenerateSchedule(e.NewContext(req, rec)); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "weight_role_recency must be a number") {
+			t.Fatalf("got %d %s, want the weight_role_recency error", rec.Code, rec.Body.String())
+		}
+	}
+}
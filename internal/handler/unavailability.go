@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"midweek-project/internal/service"
+	"midweek-project/internal/store"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const apiDateLayout = "2006-01-02"
+
+type unavailabilityRequest struct {
+	Name   string `json:"name"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+func ListUnavailabilities(c echo.Context) error {
+	entries, err := service.ListUnavailabilities(c.QueryParam("congregation"))
+	if err != nil {
+		return congregationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func CreateUnavailability(c echo.Context) error {
+	var req unavailabilityRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing name",
+		})
+	}
+
+	from, err := time.Parse(apiDateLayout, req.From)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "from must be a date in the format YYYY-MM-DD",
+		})
+	}
+
+	to := from
+	if req.To != "" {
+		if to, err = time.Parse(apiDateLayout, req.To); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "to must be a date in the format YYYY-MM-DD",
+			})
+		}
+	}
+	if to.Before(from) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "to must not be before from",
+		})
+	}
+
+	entry, err := service.CreateUnavailability(c.QueryParam("congregation"), name, from, to, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return congregationError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, entry)
+}
+
+func DeleteUnavailability(c echo.Context) error {
+	err := service.DeleteUnavailability(c.QueryParam("congregation"), c.Param("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Unavailability not found",
+		})
+	}
+	if err != nil {
+		return congregationError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,105 @@
+package locale
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(&Locale{
+		Code: "en",
+		Name: "English",
+		Tag:  "en-US",
+
+		DateLayout: "01/02/2006",
+		Headings: map[string]string{
+			SectionTreasures: "Treasures From God's Word",
+			SectionMinistry:  "Apply Yourself to the Field Ministry",
+			SectionChristian: "Living as Christians",
+		},
+		Months: map[string]time.Month{
+			"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+			"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+			"september": time.September, "october": time.October, "november": time.November,
+			"december": time.December,
+		},
+		Date: regexp.MustCompile(`(?i)(?P<start_month>[a-z]+)\s+(?P<start_day>\d{1,2})\s*[-–]\s*(?:(?P<end_month>[a-z]+)\s+)?(?P<end_day>\d{1,2})\b`),
+		FormatWeek: func(startDay, startMonth, endDay, endMonth string) string {
+			if endMonth != "" && endMonth != startMonth {
+				return fmt.Sprintf("%s %s–%s %s", title(startMonth), startDay, title(endMonth), endDay)
+			}
+			return fmt.Sprintf("%s %s-%s", title(startMonth), startDay, endDay)
+		},
+		Song:     regexp.MustCompile(`(?i)Song[\s\xA0]+(\d+)`),
+		Study:    regexp.MustCompile(`(?i)\b(th|lmd)\s+(?:study|lesson)\s+(\d{1,3})(?:\s+point\s+\d{1,2})?`),
+		Comments: regexp.MustCompile(`(?i)^(?:opening|concluding)\s+comments`),
+		KindRules: []KindRule{
+			{SectionTreasures, "bible reading", KindBibleReading},
+			{SectionTreasures, "spiritual gems", KindSpiritualGems},
+			{SectionMinistry, "talk", KindStudentTalk},
+			{SectionMinistry, "starting a conversation", KindStartingConversation},
+			{SectionMinistry, "following up", KindFollowingUp},
+			{SectionMinistry, "making disciples", KindMakingDisciples},
+			{SectionMinistry, "explaining your beliefs", KindExplainingBeliefs},
+			{SectionChristian, "congregation bible study", KindCongregationStudy},
+			{SectionChristian, "local needs", KindLocalNeeds},
+		},
+		WeekMarkers: []WeekMarker{
+			{"circuit overseer's visit", WeekCircuitOverseer},
+			{"visit of the circuit overseer", WeekCircuitOverseer},
+			{"assembly week", WeekAssembly},
+			{"convention week", WeekAssembly},
+			{"memorial week", WeekMemorial},
+		},
+		WeekNames: map[string]string{
+			"co visit": WeekCircuitOverseer, "circuit overseer": WeekCircuitOverseer,
+			"convention": WeekAssembly,
+		},
+		Labels: Labels{
+			Week:               "Week",
+			Chairman:           "Chairman",
+			AuxiliaryCounselor: "Auxiliary classroom counselor",
+			ThirdCounselor:     "Third classroom counselor",
+			OpeningSong:        "Opening song",
+			Prayer:             "Prayer",
+			OpeningComments:    "Opening Comments (1 min.)",
+			MidSong:            "Song",
+			ConductorReader:    "Conductor/Reader",
+			ConcludingComments: "Concluding Comments (3 min.)",
+			ClosingSong:        "Closing song",
+			HallMain:           "Main hall",
+			HallAuxiliary:      "Auxiliary classroom",
+			HallThird:          "Third classroom",
+			EndsAt:             "Ends",
+			Overrun:            "Over by %d min (%s)",
+			ServiceTalk:        "Service talk (30 min.)",
+			CircuitOverseer:    "Circuit overseer",
+			NoMeetingAssembly:  "No meeting: assembly or convention week",
+			NoMeetingMemorial:  "No meeting: Memorial week",
+			Workbook:           "Workbook",
+
+			SlipTitle:      "OUR CHRISTIAN LIFE AND MINISTRY",
+			SlipSubtitle:   "MEETING ASSIGNMENT",
+			SlipName:       "Name",
+			SlipAssistant:  "Assistant",
+			SlipDate:       "Date",
+			SlipPart:       "Part number",
+			SlipStudyPoint: "Study point",
+			SlipMaterial:   "Material",
+			SlipLocation:   "To be given in",
+			SlipNote: "Note to student: The source material and study point for your assignment can be " +
+				"found in the Life and Ministry Meeting Workbook. Please review the guidelines for the part " +
+				"found in the Instructions for Our Christian Life and Ministry Meeting (S-38).",
+			SlipFormCode: "S-89-E 11/23",
+		},
+	})
+}
+
+func title(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
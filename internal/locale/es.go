@@ -0,0 +1,97 @@
+package locale
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+func init() {
+	register(&Locale{
+		Code: "es",
+		Name: "Español",
+		Tag:  "es-ES",
+
+		DateLayout: "02/01/2006",
+		Headings: map[string]string{
+			SectionTreasures: "Tesoros de la Biblia",
+			SectionMinistry:  "Seamos mejores maestros",
+			SectionChristian: "Nuestra vida cristiana",
+		},
+		Months: map[string]time.Month{
+			"enero": time.January, "febrero": time.February, "marzo": time.March, "abril": time.April,
+			"mayo": time.May, "junio": time.June, "julio": time.July, "agosto": time.August,
+			"septiembre": time.September, "setiembre": time.September, "octubre": time.October,
+			"noviembre": time.November, "diciembre": time.December,
+		},
+		Date: regexp.MustCompile(`(?i)\b(?P<start_day>\d{1,2})(?:\s+de\s+(?P<start_month>[a-zñ]+))?(?:\s+de\s+(?P<start_year>\d{4}))?(?:\s+al?\s+|\s*[-–]\s*)(?P<end_day>\d{1,2})\s+de\s+(?P<end_month>[a-zñ]+)(?:\s+de\s+(?P<end_year>\d{4}))?`),
+		FormatWeek: func(startDay, startMonth, endDay, endMonth string) string {
+			if startMonth != "" {
+				return fmt.Sprintf("%s de %s a %s de %s", startDay, startMonth, endDay, endMonth)
+			}
+			return fmt.Sprintf("%s a %s de %s", startDay, endDay, endMonth)
+		},
+		Song:     regexp.MustCompile(`(?i)Canci[óo]n[\s\xA0]+(\d+)`),
+		Study:    regexp.MustCompile(`(?i)\b(th|lmd)\s+lecci[óo]n\s+(\d{1,3})(?:\s+punto\s+\d{1,2})?`),
+		Comments: regexp.MustCompile(`(?i)^palabras\s+de\s+(?:introducci[óo]n|conclusi[óo]n)`),
+		KindRules: []KindRule{
+			{SectionTreasures, "lectura de la biblia", KindBibleReading},
+			{SectionTreasures, "perlas escondidas", KindSpiritualGems},
+			{SectionMinistry, "discurso", KindStudentTalk},
+			{SectionMinistry, "empiece conversaciones", KindStartingConversation},
+			{SectionMinistry, "haga revisitas", KindFollowingUp},
+			{SectionMinistry, "haga discípulos", KindMakingDisciples},
+			{SectionMinistry, "explique sus creencias", KindExplainingBeliefs},
+			{SectionChristian, "estudio bíblico de la congregación", KindCongregationStudy},
+			{SectionChristian, "necesidades de la congregación", KindLocalNeeds},
+		},
+		WeekMarkers: []WeekMarker{
+			{"visita del superintendente de circuito", WeekCircuitOverseer},
+			{"semana de la asamblea", WeekAssembly},
+			{"semana de asamblea", WeekAssembly},
+			{"semana de la conmemoración", WeekMemorial},
+		},
+		WeekNames: map[string]string{
+			"normal": WeekRegular, "visita": WeekCircuitOverseer,
+			"visita del superintendente": WeekCircuitOverseer, "visita del superintendente de circuito": WeekCircuitOverseer,
+			"asamblea": WeekAssembly, "conmemoración": WeekMemorial, "conmemoracion": WeekMemorial,
+		},
+		Labels: Labels{
+			Week:               "Semana",
+			Chairman:           "Presidente",
+			AuxiliaryCounselor: "Consejero sala B",
+			ThirdCounselor:     "Consejero sala C",
+			OpeningSong:        "Canción inicial",
+			Prayer:             "Oración",
+			OpeningComments:    "Palabras de introducción (1 min)",
+			MidSong:            "Canción",
+			ConductorReader:    "Conductor/Lector",
+			ConcludingComments: "Palabras de conclusión (3 mins.)",
+			ClosingSong:        "Canción final",
+			HallMain:           "Sala principal",
+			HallAuxiliary:      "Sala B",
+			HallThird:          "Sala C",
+			EndsAt:             "Termina",
+			Overrun:            "Excede %d min (%s)",
+			ServiceTalk:        "Discurso de servicio (30 mins.)",
+			CircuitOverseer:    "Superintendente de circuito",
+			NoMeetingAssembly:  "No hay reunión: semana de asamblea",
+			NoMeetingMemorial:  "No hay reunión: semana de la Conmemoración",
+			Workbook:           "Guía de actividades",
+
+			SlipTitle:      "ASIGNACIÓN PARA LA REUNIÓN",
+			SlipSubtitle:   "VIDA Y MINISTERIO CRISTIANOS",
+			SlipName:       "Nombre",
+			SlipAssistant:  "Ayudante",
+			SlipDate:       "Fecha",
+			SlipPart:       "Intervención núm.",
+			SlipStudyPoint: "Lección",
+			SlipMaterial:   "Material",
+			SlipLocation:   "Se presentará en",
+			SlipNote: "Nota al estudiante: En la Guía de actividades encontrará la información que necesita " +
+				"para su intervención, así como la lección que debe estudiar. Repase también las Indicaciones " +
+				"para la reunión Vida y Ministerio Cristianos (S-38).",
+			SlipFormCode: "S-89-S 11/23",
+		},
+	})
+}
@@ -0,0 +1,173 @@
+package locale
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Identificadores das seções, tipos de parte e tipos de semana, comuns a todos
+// os idiomas. O pacote parser os usa como valores dos seus próprios tipos.
+const (
+	SectionTreasures = "treasures"
+	SectionMinistry  = "ministry"
+	SectionChristian = "christian_living"
+
+	KindSpiritualGems        = "spiritual_gems"
+	KindBibleReading         = "bible_reading"
+	KindStartingConversation = "starting_conversation"
+	KindFollowingUp          = "following_up"
+	KindMakingDisciples      = "making_disciples"
+	KindExplainingBeliefs    = "explaining_beliefs"
+	KindStudentTalk          = "student_talk"
+	KindLocalNeeds           = "local_needs"
+	KindCongregationStudy    = "congregation_study"
+
+	WeekRegular         = "regular"
+	WeekCircuitOverseer = "circuit_overseer"
+	WeekAssembly        = "assembly"
+	WeekMemorial        = "memorial"
+)
+
+const Default = "pt"
+
+// KindRule associa um trecho do título, em minúsculas, ao tipo da parte.
+type KindRule struct {
+	Section string
+	Match   string
+	Kind    string
+}
+
+// WeekMarker é uma frase de aviso da apostila que indica o tipo da semana.
+type WeekMarker struct {
+	Match string
+	Week  string
+}
+
+// Locale reúne tudo o que depende do idioma da apostila e dos documentos gerados.
+type Locale struct {
+	Code string
+	Name string
+	// Tag é a etiqueta de idioma gravada nos documentos do Word, como "pt-BR".
+	Tag string
+	// DateLayout formata datas completas, como o dia da reunião nas papeletas.
+	DateLayout string
+
+	// Headings traz o título de cada seção como aparece na apostila.
+	Headings map[string]string
+	Months   map[string]time.Month
+	// Date casa a data da semana com os grupos start_day, start_month, end_day
+	// e end_month; um dos meses pode faltar e vale o outro. Os grupos
+	// opcionais start_year e end_year trazem o ano quando a apostila o mostra.
+	Date *regexp.Regexp
+	// FormatWeek monta o rótulo da semana a partir das partes da data, com os
+	// meses já em minúsculas (start_month pode ser vazio).
+	FormatWeek func(startDay, startMonth, endDay, endMonth string) string
+	Song       *regexp.Regexp
+	// Study casa uma lição de conselho, com a brochura no grupo 1 e o número no 2.
+	Study *regexp.Regexp
+	// Comments casa os comentários iniciais e finais do presidente.
+	Comments    *regexp.Regexp
+	KindRules   []KindRule
+	WeekMarkers []WeekMarker
+	WeekNames   map[string]string
+
+	Labels Labels
+}
+
+// Labels são os textos fixos da programação e das papeletas S-89.
+type Labels struct {
+	Week               string
+	Chairman           string
+	AuxiliaryCounselor string
+	ThirdCounselor     string
+	OpeningSong        string
+	Prayer             string
+	OpeningComments    string
+	MidSong            string
+	ConductorReader    string
+	ConcludingComments string
+	ClosingSong        string
+	HallMain           string
+	HallAuxiliary      string
+	HallThird          string
+	EndsAt             string
+	// Overrun recebe os minutos excedentes e o horário de término.
+	Overrun           string
+	ServiceTalk       string
+	CircuitOverseer   string
+	NoMeetingAssembly string
+	NoMeetingMemorial string
+	Workbook          string
+
+	SlipTitle      string
+	SlipSubtitle   string
+	SlipName       string
+	SlipAssistant  string
+	SlipDate       string
+	SlipPart       string
+	SlipStudyPoint string
+	SlipMaterial   string
+	SlipLocation   string
+	SlipNote       string
+	SlipFormCode   string
+}
+
+var registry = map[string]*Locale{}
+
+func register(l *Locale) {
+	registry[l.Code] = l
+}
+
+// Get devolve o pacote do idioma; código vazio vale o padrão.
+func Get(code string) (*Locale, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		code = Default
+	}
+	if l, ok := registry[code]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported locale %q, expected one of %s", code, strings.Join(Codes(), ", "))
+}
+
+// MustGet é Get para códigos conhecidos em tempo de compilação.
+func MustGet(code string) *Locale {
+	l, err := Get(code)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func Codes() []string {
+	codes := make([]string, 0, len(registry))
+	for code := range registry {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// All devolve todos os pacotes, em ordem de código.
+func All() []*Locale {
+	var all []*Locale
+	for _, code := range Codes() {
+		all = append(all, registry[code])
+	}
+	return all
+}
+
+// MatchHeading devolve a seção cujo título é a linha, ignorando caixa e o tipo
+// de apóstrofo.
+func (l *Locale) MatchHeading(line string) string {
+	line = strings.ReplaceAll(line, "’", "'")
+	for section, heading := range l.Headings {
+		if strings.EqualFold(line, heading) {
+			return section
+		}
+	}
+	return ""
+}
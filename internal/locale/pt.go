@@ -0,0 +1,99 @@
+package locale
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+func init() {
+	register(&Locale{
+		Code: "pt",
+		Name: "Português",
+		Tag:  "pt-BR",
+
+		DateLayout: "02/01/2006",
+		Headings: map[string]string{
+			SectionTreasures: "Tesouros da Palavra de Deus",
+			SectionMinistry:  "Faça seu melhor no ministério",
+			SectionChristian: "Nossa vida cristã",
+		},
+		Months: map[string]time.Month{
+			"janeiro": time.January, "fevereiro": time.February, "março": time.March, "marco": time.March,
+			"abril": time.April, "maio": time.May, "junho": time.June, "julho": time.July,
+			"agosto": time.August, "setembro": time.September, "outubro": time.October,
+			"novembro": time.November, "dezembro": time.December,
+		},
+		Date: regexp.MustCompile(`(?i)\b(?P<start_day>\d{1,2})(?:\s+de\s+(?P<start_month>[a-zç]+))?(?:\s+de\s+(?P<start_year>\d{4}))?(?:\s+a\s+|\s*[-–]\s*)(?P<end_day>\d{1,2})(?:º?\.?|\.º)?\s+de\s+(?P<end_month>[a-zç]+)(?:\s+de\s+(?P<end_year>\d{4}))?`),
+		FormatWeek: func(startDay, startMonth, endDay, endMonth string) string {
+			if startMonth != "" {
+				return fmt.Sprintf("%s de %s a %s de %s", startDay, startMonth, endDay, endMonth)
+			}
+			return fmt.Sprintf("%s a %s de %s", startDay, endDay, endMonth)
+		},
+		Song:     regexp.MustCompile(`(?i)C[âa]ntico[\s\xA0]+(\d+)`),
+		Study:    regexp.MustCompile(`(?i)\b(th|lmd)\s+lição\s+(\d{1,3})(?:\s+ponto\s+\d{1,2})?`),
+		Comments: regexp.MustCompile(`(?i)^coment[áa]rios\s+(?:iniciais|finais)`),
+		KindRules: []KindRule{
+			{SectionTreasures, "leitura da bíblia", KindBibleReading},
+			{SectionTreasures, "joias espirituais", KindSpiritualGems},
+			{SectionMinistry, "discurso", KindStudentTalk},
+			{SectionMinistry, "iniciando conversas", KindStartingConversation},
+			{SectionMinistry, "cultivando o interesse", KindFollowingUp},
+			{SectionMinistry, "fazendo discípulos", KindMakingDisciples},
+			{SectionMinistry, "explicando suas crenças", KindExplainingBeliefs},
+			{SectionChristian, "estudo bíblico de congregação", KindCongregationStudy},
+			{SectionChristian, "necessidades locais", KindLocalNeeds},
+		},
+		WeekMarkers: []WeekMarker{
+			{"visita do superintendente de circuito", WeekCircuitOverseer},
+			{"semana da assembleia", WeekAssembly},
+			{"semana de assembleia", WeekAssembly},
+			{"semana do congresso", WeekAssembly},
+			{"semana da celebração", WeekMemorial},
+		},
+		WeekNames: map[string]string{
+			"normal": WeekRegular, "visita": WeekCircuitOverseer,
+			"visita do superintendente": WeekCircuitOverseer, "visita do superintendente de circuito": WeekCircuitOverseer,
+			"assembleia": WeekAssembly, "congresso": WeekAssembly,
+			"celebração": WeekMemorial, "celebracao": WeekMemorial,
+		},
+		Labels: Labels{
+			Week:               "Semana",
+			Chairman:           "Presidente",
+			AuxiliaryCounselor: "Conselheiro sala B",
+			ThirdCounselor:     "Conselheiro sala C",
+			OpeningSong:        "Cântico Inicial",
+			Prayer:             "Oração",
+			OpeningComments:    "Comentários iniciais (1 min)",
+			MidSong:            "Cântico",
+			ConductorReader:    "Dirigente/Leitor",
+			ConcludingComments: "Comentários finais (3 min)",
+			ClosingSong:        "Cântico Final",
+			HallMain:           "Salão principal",
+			HallAuxiliary:      "Sala B",
+			HallThird:          "Sala C",
+			EndsAt:             "Término",
+			Overrun:            "Excede %d min (%s)",
+			ServiceTalk:        "Discurso de serviço (30 min)",
+			CircuitOverseer:    "Superintendente de circuito",
+			NoMeetingAssembly:  "Não há reunião: semana de assembleia ou congresso",
+			NoMeetingMemorial:  "Não há reunião: semana da Celebração",
+			Workbook:           "Apostila",
+
+			SlipTitle:      "DESIGNAÇÃO PARA A REUNIÃO",
+			SlipSubtitle:   "NOSSA VIDA E MINISTÉRIO CRISTÃO",
+			SlipName:       "Nome",
+			SlipAssistant:  "Ajudante",
+			SlipDate:       "Data",
+			SlipPart:       "Número da parte",
+			SlipStudyPoint: "Ponto de estudo",
+			SlipMaterial:   "Matéria",
+			SlipLocation:   "Local",
+			SlipNote: "Observação para o estudante: A lição e a fonte de matéria para a sua designação " +
+				"estão na Apostila da Reunião Vida e Ministério. Veja as instruções para a parte que estão nas " +
+				"Instruções para a Reunião Nossa Vida e Ministério Cristão (S-38).",
+			SlipFormCode: "S-89-T 11/23",
+		},
+	})
+}
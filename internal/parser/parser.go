@@ -2,107 +2,382 @@ package parser
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"midweek-project/internal/locale"
 	"midweek-project/internal/util"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
-type Section map[string]string
-
-type MeetingData struct {
-	MeetingDate                     string
-	InitSong                        string
-	MidSong                         string
-	FinalSong                       string
-	TreasuresFromGodsWord           Section
-	ApplyYourselfToTheFieldMinistry Section
-	LivingAsChristians              Section
-	Designated                      map[string]string
-}
+// Section é uma das três seções da reunião. O título de cada uma, no idioma da
+// apostila, vem do pacote locale.
+type Section string
 
 const (
-	SectionTreasures = "TESOUROS DA PALAVRA DE DEUS"
-	SectionMinistry  = "FAÇA SEU MELHOR NO MINISTÉRIO"
-	SectionChristian = "NOSSA VIDA CRISTÃ"
+	SectionTreasures Section = locale.SectionTreasures
+	SectionMinistry  Section = locale.SectionMinistry
+	SectionChristian Section = locale.SectionChristian
 )
 
-var (
-	reTopic = regexp.MustCompile(`(?m)^\s*(\d{1,3})[.\xEF\xBC\x8E]?\s+(.+?)\s*\(\s*(\d{1,3})\s*(?:minutos|min)\s*\)`)
-	reSong  = regexp.MustCompile(`(?i)C[âa]ntico[\s\xA0]+(\d+)`)
-	reDate  = regexp.MustCompile(`(?i)(\d{1,2})(?:\s+de\s+([a-zç]+))?\s+a\s+(\d{1,2})(?:º?\.?|\.º)?\s+de\s+([a-zç]+)`)
+var Sections = []Section{SectionTreasures, SectionMinistry, SectionChristian}
+
+// PartKind é o tipo de parte, que decide quem pode ser designado e quantas
+// pessoas ela recebe.
+type PartKind string
+
+const (
+	KindTreasuresTalk        PartKind = "treasures_talk"
+	KindSpiritualGems        PartKind = locale.KindSpiritualGems
+	KindBibleReading         PartKind = locale.KindBibleReading
+	KindStartingConversation PartKind = locale.KindStartingConversation
+	KindFollowingUp          PartKind = locale.KindFollowingUp
+	KindMakingDisciples      PartKind = locale.KindMakingDisciples
+	KindExplainingBeliefs    PartKind = locale.KindExplainingBeliefs
+	KindStudentTalk          PartKind = locale.KindStudentTalk
+	KindMinistry             PartKind = "ministry"
+	KindLocalNeeds           PartKind = locale.KindLocalNeeds
+	KindCongregationStudy    PartKind = locale.KindCongregationStudy
+	KindChristianLiving      PartKind = "christian_living"
 )
 
-func ParseAllMeetings(contents []string) ([]MeetingData, error) {
+// Part é uma parte numerada da apostila. Description traz o que vem depois do
+// título: textos, cenário e referências, uma linha por parágrafo.
+type Part struct {
+	Number      int
+	Title       string
+	Minutes     int
+	Section     Section
+	Kind        PartKind
+	Description string
+	// StudyReference é a lição de conselho citada, como "th lição 10" ou
+	// "lmd lição 1 ponto 3", e StudyPoint o número da lição (zero se não houver).
+	// Ambos seguem o idioma da apostila.
+	StudyReference string
+	StudyPoint     int
+}
+
+// Key é a chave da parte em MeetingData.Designated; partes com duas salas usam
+// Key()+".A" e Key()+".B".
+func (p Part) Key() string {
+	return strconv.Itoa(p.Number)
+}
+
+// Heading é a linha da parte como aparece na programação: "3. Título (4 min)".
+func (p Part) Heading() string {
+	return fmt.Sprintf("%d. %s (%d min)", p.Number, p.Title, p.Minutes)
+}
+
+type MeetingData struct {
+	MeetingDate string
+	StartDate   time.Time
+	EndDate     time.Time
+	// Date é o dia da reunião dentro da semana; fica zero enquanto o dia da
+	// semana da congregação não for informado.
+	Date      time.Time
+	Type      WeekType
+	InitSong  string
+	MidSong   string
+	FinalSong string
+	// Parts traz as partes na ordem da apostila.
+	Parts      []Part
+	Designated map[string]string
+}
+
+// SetMeetingDay preenche Date com o dia da semana da reunião de cada semana.
+func SetMeetingDay(meetings []MeetingData, day time.Weekday) {
+	for i := range meetings {
+		start := meetings[i].StartDate
+		offset := (int(day) - int(start.Weekday()) + 7) % 7
+		meetings[i].Date = start.AddDate(0, 0, offset)
+	}
+}
+
+// PartsIn devolve, em ordem, as partes de uma seção.
+func (m MeetingData) PartsIn(section Section) []Part {
+	var parts []Part
+	for _, part := range m.Parts {
+		if part.Section == section {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+var defaultKinds = map[Section]PartKind{
+	SectionTreasures: KindTreasuresTalk,
+	SectionMinistry:  KindMinistry,
+	SectionChristian: KindChristianLiving,
+}
+
+var reTopic = regexp.MustCompile(`(?m)^\s*(\d{1,3})[.\xEF\xBC\x8E]?\s+(.+?)\s*\(\s*(\d{1,3})\s*(?:minutos|minutes|mins?)\.?\s*\)`)
+
+// weekDate são as partes da data da semana como aparecem na apostila, com os
+// meses em minúsculas. Os anos ficam vazios quando a apostila não os mostra.
+type weekDate struct {
+	startDay, startMonth, endDay, endMonth string
+	startYear, endYear                     string
+}
+
+// DateReference diz a que ano pertencem as semanas que a apostila traz sem ano.
+// Month, quando conhecido, é o mês da apostila e resolve a primeira semana pela
+// proximidade; sem ele, a primeira semana termina em Year.
+type DateReference struct {
+	Year  int
+	Month time.Month
+}
+
+// ErrUnknownYear indica que uma semana veio só com dia e mês e não há ano de
+// referência para ela.
+var ErrUnknownYear = errors.New("year of the meetings is unknown")
+
+// ParseAllMeetings lê as semanas na ordem recebida. O ano escrito na data da
+// semana vale sobre qualquer outro; sem ele, cada semana depois da primeira
+// fica no ano que a deixa mais perto da semana anterior, de modo que uma
+// apostila que passa de dezembro para janeiro muda de ano sozinha.
+func ParseAllMeetings(contents []string, ref DateReference, loc *locale.Locale) ([]MeetingData, error) {
 	var meetings []MeetingData
+	var previous time.Time
 	for _, content := range contents {
-		meeting := parseTxtMeeting(content)
-		if meeting.MeetingDate != "" {
-			meetings = append(meetings, meeting)
+		meeting, date := parseTxtMeeting(content, loc)
+		if meeting.MeetingDate == "" {
+			continue
 		}
+
+		year := ref.Year
+		var anchor time.Time
+		switch {
+		case !previous.IsZero():
+			anchor = previous.AddDate(0, 0, 7)
+			if year == 0 {
+				year = anchor.Year()
+			}
+		case ref.Month != 0 && year != 0:
+			anchor = time.Date(year, ref.Month, 15, 0, 0, 0, 0, time.UTC)
+		}
+		if year == 0 && date.startYear == "" && date.endYear == "" {
+			return nil, ErrUnknownYear
+		}
+		start, end, err := resolveWeek(date, loc, year, anchor)
+		if err != nil {
+			return nil, err
+		}
+		meeting.StartDate, meeting.EndDate = start, end
+		previous = end
+
+		meetings = append(meetings, meeting)
 	}
 	return meetings, nil
 }
 
-func parseTxtMeeting(content string) MeetingData {
+// resolveWeek converte "3 a 9 de março" ou "29 de dezembro a 4 de janeiro" em
+// datas. Sem ano na data, o último dia fica no ano mais próximo de anchor, ou
+// em year se anchor for zero, e o primeiro dia volta um ano quando a semana
+// atravessa o Ano-Novo.
+func resolveWeek(date weekDate, loc *locale.Locale, year int, anchor time.Time) (time.Time, time.Time, error) {
+	startDay, _ := strconv.Atoi(date.startDay)
+	endDay, _ := strconv.Atoi(date.endDay)
+
+	startMonth, hasStart := loc.Months[date.startMonth]
+	endMonth, hasEnd := loc.Months[date.endMonth]
+	switch {
+	case !hasStart && !hasEnd:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown month in meeting date %s", loc.FormatWeek(date.startDay, date.startMonth, date.endDay, date.endMonth))
+	case !hasStart:
+		startMonth = endMonth
+		if startDay > endDay {
+			// "31 a 6 de abril": o primeiro dia é do mês anterior.
+			startMonth = endMonth - 1
+		}
+	case !hasEnd:
+		endMonth = startMonth
+		if endDay < startDay {
+			// "March 31-6": o último dia é do mês seguinte.
+			endMonth = startMonth + 1
+		}
+	}
+
+	startYear, _ := strconv.Atoi(date.startYear)
+	endYear, _ := strconv.Atoi(date.endYear)
+
+	// time.Date normaliza os meses 0 e 13 para o ano vizinho.
+	var end time.Time
+	switch {
+	case endYear != 0:
+		end = time.Date(endYear, endMonth, endDay, 0, 0, 0, 0, time.UTC)
+	case startYear != 0:
+		end = time.Date(startYear, endMonth, endDay, 0, 0, 0, 0, time.UTC)
+		if end.Before(time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC)) {
+			end = end.AddDate(1, 0, 0)
+		}
+	default:
+		end = time.Date(year, endMonth, endDay, 0, 0, 0, 0, time.UTC)
+		if !anchor.IsZero() {
+			for _, candidate := range []time.Time{end.AddDate(-1, 0, 0), end.AddDate(1, 0, 0)} {
+				if absDuration(candidate.Sub(anchor)) < absDuration(end.Sub(anchor)) {
+					end = candidate
+				}
+			}
+		}
+	}
+
+	if startYear != 0 {
+		return time.Date(startYear, startMonth, startDay, 0, 0, 0, 0, time.UTC), end, nil
+	}
+	start := time.Date(end.Year(), startMonth, startDay, 0, 0, 0, 0, time.UTC)
+	if start.After(end) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	return start, end, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// IsMeetingContent indica se o texto traz uma semana da apostila, em qualquer
+// idioma conhecido, ou seja, a data da semana e ao menos um cabeçalho de seção
+// ou aviso de semana especial. Índices e capas ficam de fora.
+func IsMeetingContent(content string) bool {
+	for _, loc := range locale.All() {
+		if isMeetingContent(content, loc) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMeetingContent(content string, loc *locale.Locale) bool {
+	hasDate, hasSection := false, false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := util.NormalizeLine(scanner.Text())
+		if !hasDate {
+			if _, ok := extractDateFromLine(line, loc); ok {
+				hasDate = true
+			}
+		}
+		if !hasSection && (loc.MatchHeading(line) != "" || classifyLine(line, loc) != "") {
+			hasSection = true
+		}
+		if hasDate && hasSection {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTxtMeeting(content string, loc *locale.Locale) (MeetingData, weekDate) {
 	meeting := MeetingData{
-		TreasuresFromGodsWord:           make(Section),
-		ApplyYourselfToTheFieldMinistry: make(Section),
-		LivingAsChristians:              make(Section),
-		Designated:                      make(map[string]string),
+		Type:       WeekRegular,
+		Designated: make(map[string]string),
 	}
 
-	var currentSection string
+	var date weekDate
+	var currentSection Section
+	// describing é o índice da parte que recebe as linhas seguintes (-1 se nenhuma).
+	describing := -1
 	scanner := bufio.NewScanner(strings.NewReader(content))
 	for scanner.Scan() {
 		line := util.NormalizeLine(scanner.Text())
 
 		if meeting.MeetingDate == "" {
-			meeting.MeetingDate = extractDateFromLine(line)
-			if meeting.MeetingDate != "" {
+			if found, ok := extractDateFromLine(line, loc); ok {
+				date = found
+				meeting.MeetingDate = loc.FormatWeek(date.startDay, date.startMonth, date.endDay, date.endMonth)
 				continue
 			}
 		}
 
-		if sec := detectSection(line); sec != "" {
+		if sec := Section(loc.MatchHeading(line)); sec != "" {
 			currentSection = sec
+			describing = -1
+			continue
+		}
+
+		if week := classifyLine(line, loc); week != "" && meeting.Type == WeekRegular {
+			meeting.Type = week
+			continue
+		}
+
+		isSong := assignSongIfApplicable(&meeting, line, loc)
+		if idx := assignTopicIfApplicable(&meeting, currentSection, line, loc); idx >= 0 {
+			describing = idx
 			continue
 		}
+		if isSong || loc.Comments.MatchString(line) {
+			describing = -1
+			continue
+		}
+		if describing >= 0 && line != "" {
+			appendDescription(&meeting.Parts[describing], line)
+		}
+	}
 
-		assignSongIfApplicable(&meeting, line)
-		assignTopicIfApplicable(&meeting, currentSection, line)
+	for i := range meeting.Parts {
+		setStudyPoint(&meeting.Parts[i], loc)
 	}
+	return meeting, date
+}
 
-	return meeting
+func appendDescription(part *Part, line string) {
+	if part.Description != "" {
+		part.Description += "\n"
+	}
+	part.Description += line
 }
 
-func extractDateFromLine(line string) string {
-	match := reDate.FindStringSubmatch(line)
-	if len(match) > 0 {
-		if match[2] != "" {
-			return fmt.Sprintf("%s de %s a %s de %s", match[1], match[2], match[3], match[4])
+// setStudyPoint usa a lição do "th" quando houver, que é a de conselho; senão a
+// primeira lição do "lmd".
+func setStudyPoint(part *Part, loc *locale.Locale) {
+	var chosen []string
+	for _, match := range loc.Study.FindAllStringSubmatch(part.Description, -1) {
+		if chosen == nil || (strings.EqualFold(match[1], "th") && !strings.EqualFold(chosen[1], "th")) {
+			chosen = match
 		}
-		return fmt.Sprintf("%s a %s de %s", match[1], match[3], match[4])
 	}
-	return ""
+	if chosen == nil {
+		return
+	}
+	part.StudyReference = chosen[0]
+	part.StudyPoint, _ = strconv.Atoi(chosen[2])
 }
 
-func detectSection(line string) string {
-	switch {
-	case strings.EqualFold(line, "Tesouros da Palavra de Deus"):
-		return SectionTreasures
-	case strings.EqualFold(line, "FAÇA SEU MELHOR NO MINISTÉRIO"):
-		return SectionMinistry
-	case strings.EqualFold(line, "Nossa vida cristã"):
-		return SectionChristian
-	default:
-		return ""
+// extractDateFromLine procura a data da semana na linha; meses desconhecidos
+// descartam o trecho, para que "Song 12-13" não vire data.
+func extractDateFromLine(line string, loc *locale.Locale) (weekDate, bool) {
+	match := loc.Date.FindStringSubmatch(line)
+	if match == nil {
+		return weekDate{}, false
+	}
+	date := weekDate{
+		startDay:   match[loc.Date.SubexpIndex("start_day")],
+		startMonth: strings.ToLower(match[loc.Date.SubexpIndex("start_month")]),
+		endDay:     match[loc.Date.SubexpIndex("end_day")],
+		endMonth:   strings.ToLower(match[loc.Date.SubexpIndex("end_month")]),
+	}
+	if i := loc.Date.SubexpIndex("start_year"); i >= 0 {
+		date.startYear = match[i]
+	}
+	if i := loc.Date.SubexpIndex("end_year"); i >= 0 {
+		date.endYear = match[i]
 	}
+	for _, month := range []string{date.startMonth, date.endMonth} {
+		if _, ok := loc.Months[month]; month != "" && !ok {
+			return weekDate{}, false
+		}
+	}
+	return date, true
 }
 
-func assignSongIfApplicable(meeting *MeetingData, line string) {
-	if !reSong.MatchString(line) {
-		return
+func assignSongIfApplicable(meeting *MeetingData, line string, loc *locale.Locale) bool {
+	if !loc.Song.MatchString(line) {
+		return false
 	}
 	switch {
 	case meeting.InitSong == "":
@@ -112,25 +387,54 @@ func assignSongIfApplicable(meeting *MeetingData, line string) {
 	case meeting.FinalSong == "":
 		meeting.FinalSong = line
 	}
+	return true
 }
 
-func assignTopicIfApplicable(meeting *MeetingData, section, line string) {
-	if section == "" || !reTopic.MatchString(line) {
-		return
+// assignTopicIfApplicable registra a parte da linha e devolve seu índice em
+// meeting.Parts, ou -1 se a linha não for o título de uma parte.
+func assignTopicIfApplicable(meeting *MeetingData, section Section, line string, loc *locale.Locale) int {
+	if section == "" {
+		return -1
 	}
-	match := reTopic.FindStringSubmatch(line)
-	if len(match) == 0 {
-		return
+	match := reTopic.FindStringSubmatchIndex(line)
+	if match == nil {
+		return -1
 	}
-	topicNum := match[1]
-	topicText := match[0]
+	number, _ := strconv.Atoi(line[match[2]:match[3]])
+	minutes, _ := strconv.Atoi(line[match[6]:match[7]])
+	title := strings.TrimSpace(line[match[4]:match[5]])
 
-	switch section {
-	case SectionTreasures:
-		meeting.TreasuresFromGodsWord[topicNum] = topicText
-	case SectionMinistry:
-		meeting.ApplyYourselfToTheFieldMinistry[topicNum] = topicText
-	case SectionChristian:
-		meeting.LivingAsChristians[topicNum] = topicText
+	part := Part{
+		Number:  number,
+		Title:   title,
+		Minutes: minutes,
+		Section: section,
+		Kind:    detectKind(section, title, loc),
+	}
+	// O restante da linha do título já é o começo da descrição.
+	if rest := strings.TrimSpace(line[match[1]:]); rest != "" {
+		part.Description = rest
+	}
+
+	// Um número repetido substitui a parte anterior, como a linha mais recente.
+	for i := range meeting.Parts {
+		if meeting.Parts[i].Number == number {
+			meeting.Parts[i] = part
+			return i
+		}
+	}
+	meeting.Parts = append(meeting.Parts, part)
+	return len(meeting.Parts) - 1
+}
+
+// detectKind aplica as regras do idioma na ordem; sem nenhuma, vale o tipo
+// padrão da seção.
+func detectKind(section Section, title string, loc *locale.Locale) PartKind {
+	lower := strings.ToLower(title)
+	for _, rule := range loc.KindRules {
+		if Section(rule.Section) == section && strings.Contains(lower, rule.Match) {
+			return PartKind(rule.Kind)
+		}
 	}
+	return defaultKinds[section]
 }
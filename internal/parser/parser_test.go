@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"errors"
+	"midweek-project/internal/locale"
+	"strings"
+	"testing"
+)
+
+// weekText monta uma semana mínima da apostila em português.
+func weekText(date string) string {
+	return date + `
+ISAÍAS 1-2
+Cântico 12 e oração | Comentários iniciais (1 min)
+TESOUROS DA PALAVRA DE DEUS
+1. Algo importante (10 min)
+FAÇA SEU MELHOR NO MINISTÉRIO
+4. Iniciando conversas (3 min) DE CASA EM CASA. (lmd lição 1 ponto 3)
+NOSSA VIDA CRISTÃ
+Cântico 50
+8. Estudo bíblico de congregação (30 min)
+Comentários finais (3 min) | Cântico 100 e oração
+`
+}
+
+func TestParseAllMeetingsYearBoundary(t *testing.T) {
+	december := []string{
+		weekText("15 a 21 de dezembro"),
+		weekText("22 a 28 de dezembro"),
+		weekText("29 de dezembro a 4 de janeiro"),
+		weekText("5 a 11 de janeiro"),
+	}
+
+	tests := []struct {
+		name     string
+		contents []string
+		ref      DateReference
+		want     [][2]string
+	}{
+		{
+			name:     "december workbook",
+			contents: december,
+			ref:      DateReference{Year: 2025, Month: 12},
+			want: [][2]string{
+				{"2025-12-15", "2025-12-21"},
+				{"2025-12-22", "2025-12-28"},
+				{"2025-12-29", "2026-01-04"},
+				{"2026-01-05", "2026-01-11"},
+			},
+		},
+		{
+			name:     "year without month",
+			contents: december,
+			ref:      DateReference{Year: 2025},
+			want: [][2]string{
+				{"2025-12-15", "2025-12-21"},
+				{"2025-12-22", "2025-12-28"},
+				{"2025-12-29", "2026-01-04"},
+				{"2026-01-05", "2026-01-11"},
+			},
+		},
+		{
+			name:     "january workbook starting in december",
+			contents: december[2:],
+			ref:      DateReference{Year: 2026, Month: 1},
+			want: [][2]string{
+				{"2025-12-29", "2026-01-04"},
+				{"2026-01-05", "2026-01-11"},
+			},
+		},
+		{
+			name: "workbook header with years",
+			contents: []string{
+				weekText("29 de dezembro de 2025 a 4 de janeiro de 2026"),
+				weekText("5 a 11 de janeiro"),
+			},
+			ref: DateReference{Year: 2026, Month: 1},
+			want: [][2]string{
+				{"2025-12-29", "2026-01-04"},
+				{"2026-01-05", "2026-01-11"},
+			},
+		},
+		{
+			name:     "years in the header win over the reference",
+			contents: []string{weekText("29 de dezembro de 2025 a 4 de janeiro de 2026")},
+			ref:      DateReference{Year: 2030, Month: 6},
+			want:     [][2]string{{"2025-12-29", "2026-01-04"}},
+		},
+		{
+			name: "years in the header without a reference",
+			contents: []string{
+				weekText("29 de dezembro de 2025 a 4 de janeiro de 2026"),
+				weekText("5 a 11 de janeiro"),
+			},
+			want: [][2]string{
+				{"2025-12-29", "2026-01-04"},
+				{"2026-01-05", "2026-01-11"},
+			},
+		},
+		{
+			name:     "start year only",
+			contents: []string{weekText("29 de dezembro de 2025 a 4 de janeiro")},
+			want:     [][2]string{{"2025-12-29", "2026-01-04"}},
+		},
+		{
+			name:     "january workbook with the year of the last week",
+			contents: december[1:],
+			ref:      DateReference{Year: 2026, Month: 1},
+			want: [][2]string{
+				{"2025-12-22", "2025-12-28"},
+				{"2025-12-29", "2026-01-04"},
+				{"2026-01-05", "2026-01-11"},
+			},
+		},
+	}
+
+	loc := locale.MustGet(locale.Default)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meetings, err := ParseAllMeetings(tt.contents, tt.ref, loc)
+			if err != nil {
+				t.Fatalf("ParseAllMeetings: %v", err)
+			}
+			if len(meetings) != len(tt.want) {
+				t.Fatalf("got %d meetings, want %d", len(meetings), len(tt.want))
+			}
+			for i, m := range meetings {
+				if strings.Contains(m.MeetingDate, "20") {
+					t.Errorf("label %q keeps the year", m.MeetingDate)
+				}
+				start, end := m.StartDate.Format("2006-01-02"), m.EndDate.Format("2006-01-02")
+				if start != tt.want[i][0] || end != tt.want[i][1] {
+					t.Errorf("%q: got %s to %s, want %s to %s", m.MeetingDate, start, end, tt.want[i][0], tt.want[i][1])
+				}
+			}
+		})
+	}
+}
+
+// TestParseAllMeetingsWorkbookHeader lê o cabeçalho exato das apostilas que
+// trazem o ano, sem que o "25" de "2025" vire o dia de início.
+func TestParseAllMeetingsWorkbookHeader(t *testing.T) {
+	meetings, err := ParseAllMeetings([]string{weekText("29 de dezembro de 2025 a 4 de janeiro de 2026")}, DateReference{}, locale.MustGet(locale.Default))
+	if err != nil {
+		t.Fatalf("ParseAllMeetings: %v", err)
+	}
+	if len(meetings) != 1 {
+		t.Fatalf("got %d meetings, want 1", len(meetings))
+	}
+	m := meetings[0]
+	if m.MeetingDate != "29 de dezembro a 4 de janeiro" {
+		t.Errorf("label %q, want %q", m.MeetingDate, "29 de dezembro a 4 de janeiro")
+	}
+	if got := m.StartDate.Format("2006-01-02"); got != "2025-12-29" {
+		t.Errorf("start %s, want 2025-12-29", got)
+	}
+	if got := m.EndDate.Format("2006-01-02"); got != "2026-01-04" {
+		t.Errorf("end %s, want 2026-01-04", got)
+	}
+}
+
+func TestParseAllMeetingsUnknownYear(t *testing.T) {
+	_, err := ParseAllMeetings([]string{weekText("3 a 9 de março")}, DateReference{}, locale.MustGet(locale.Default))
+	if !errors.Is(err, ErrUnknownYear) {
+		t.Fatalf("got %v, want ErrUnknownYear", err)
+	}
+}
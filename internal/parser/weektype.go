@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"fmt"
+	"midweek-project/internal/locale"
+	"strings"
+	"time"
+)
+
+// WeekType diz se a semana segue a programação normal ou é uma semana especial.
+type WeekType string
+
+const (
+	WeekRegular         WeekType = locale.WeekRegular
+	WeekCircuitOverseer WeekType = locale.WeekCircuitOverseer
+	WeekAssembly        WeekType = locale.WeekAssembly
+	WeekMemorial        WeekType = locale.WeekMemorial
+)
+
+const weekTypeMarkerMaxLen = 120
+
+// WeekOverride fixa o tipo da semana que contém Date, vindo da API ou da aba de
+// configuração da planilha.
+type WeekOverride struct {
+	Date time.Time
+	Type WeekType
+}
+
+// ParseWeekType aceita o identificador ("circuit_overseer") ou o nome em
+// qualquer idioma conhecido ("visita", "asamblea", "co visit").
+func ParseWeekType(value string) (WeekType, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	for _, week := range []WeekType{WeekRegular, WeekCircuitOverseer, WeekAssembly, WeekMemorial} {
+		if name == string(week) {
+			return week, nil
+		}
+	}
+	for _, loc := range locale.All() {
+		if week, ok := loc.WeekNames[name]; ok {
+			return WeekType(week), nil
+		}
+	}
+	return "", fmt.Errorf("unknown week type %q", value)
+}
+
+// HasMeeting indica se há reunião de meio de semana; nas semanas de assembleia
+// e da Celebração ela não acontece.
+func (w WeekType) HasMeeting() bool {
+	return w != WeekAssembly && w != WeekMemorial
+}
+
+// classifyLine reconhece os avisos de semana especial da apostila. Só linhas
+// curtas contam, para que o título de uma parte não mude o tipo da semana.
+func classifyLine(line string, loc *locale.Locale) WeekType {
+	if len(line) > weekTypeMarkerMaxLen {
+		return ""
+	}
+	lower := strings.ToLower(strings.ReplaceAll(line, "’", "'"))
+	for _, marker := range loc.WeekMarkers {
+		if strings.Contains(lower, marker.Match) {
+			return WeekType(marker.Week)
+		}
+	}
+	return ""
+}
+
+// ApplyWeekTypes aplica as substituições na ordem recebida, de modo que a última
+// para a mesma semana vence. Datas fora do período são ignoradas.
+func ApplyWeekTypes(meetings []MeetingData, overrides []WeekOverride) {
+	for _, override := range overrides {
+		for i := range meetings {
+			m := &meetings[i]
+			if !override.Date.Before(m.StartDate) && !override.Date.After(m.EndDate) {
+				m.Type = override.Type
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package rtf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Destinos cujo conteúdo não faz parte do texto do documento.
+var skippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "object": true, "objdata": true, "shppict": true, "nonshppict": true,
+	"header": true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"footnote": true, "annotation": true, "fldinst": true, "listtable": true,
+	"listoverridetable": true, "revtbl": true, "rsidtbl": true, "generator": true,
+	"xmlnstbl": true, "themedata": true, "colorschememapping": true, "datastore": true,
+	"latentstyles": true, "pgdsctbl": true, "bkmkstart": true, "bkmkend": true,
+	"filetbl": true, "private": true, "userprops": true, "docvar": true,
+}
+
+var symbols = map[string]string{
+	"par": "\n", "line": "\n", "sect": "\n", "page": "\n", "row": "\n", "cell": "\n",
+	"tab": "\t", "emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+	"emspace": " ", "enspace": " ", "qmspace": " ",
+}
+
+var codePages = map[int]encoding.Encoding{
+	437: charmap.CodePage437, 850: charmap.CodePage850, 852: charmap.CodePage852,
+	1250: charmap.Windows1250, 1251: charmap.Windows1251, 1252: charmap.Windows1252,
+	1253: charmap.Windows1253, 1254: charmap.Windows1254, 1255: charmap.Windows1255,
+	1256: charmap.Windows1256, 1257: charmap.Windows1257, 1258: charmap.Windows1258,
+	10000: charmap.Macintosh,
+}
+
+type groupState struct {
+	skip   bool
+	ucSkip int
+}
+
+type reader struct {
+	data          []byte
+	pos           int
+	out           strings.Builder
+	pending       []byte
+	highSurrogate rune
+	decoder       encoding.Encoding
+	state         groupState
+	stack         []groupState
+	// toSkip conta os caracteres substitutos que seguem um \uN.
+	toSkip int
+}
+
+// ToText extrai o texto de um documento RTF, com um parágrafo por linha,
+// no mesmo formato produzido pela exportação "txt:Text" do LibreOffice.
+func ToText(data []byte) (string, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\xEF\xBB\xBF")
+	if !bytes.HasPrefix(trimmed, []byte(`{\rtf`)) {
+		return "", fmt.Errorf("not an rtf document")
+	}
+
+	r := &reader{
+		data:    trimmed,
+		decoder: charmap.Windows1252,
+		state:   groupState{ucSkip: 1},
+	}
+	if err := r.parse(); err != nil {
+		return "", err
+	}
+	return r.out.String(), nil
+}
+
+func (r *reader) parse() error {
+	for r.pos < len(r.data) {
+		c := r.data[r.pos]
+		switch c {
+		case '{':
+			r.flush()
+			r.stack = append(r.stack, r.state)
+			r.toSkip = 0
+			r.pos++
+		case '}':
+			r.flush()
+			if len(r.stack) == 0 {
+				return fmt.Errorf("unbalanced group at offset %d", r.pos)
+			}
+			r.state = r.stack[len(r.stack)-1]
+			r.stack = r.stack[:len(r.stack)-1]
+			r.toSkip = 0
+			r.pos++
+		case '\\':
+			if err := r.control(); err != nil {
+				return err
+			}
+		case '\r', '\n':
+			r.pos++
+		default:
+			r.pos++
+			if r.consumeSkip() {
+				continue
+			}
+			r.flush()
+			r.emitByte(c)
+		}
+	}
+	r.flush()
+
+	if len(r.stack) != 0 {
+		return fmt.Errorf("unterminated group")
+	}
+	return nil
+}
+
+func (r *reader) control() error {
+	r.pos++
+	if r.pos >= len(r.data) {
+		return fmt.Errorf("dangling backslash at end of document")
+	}
+
+	c := r.data[r.pos]
+	switch {
+	case c == '\'':
+		if r.pos+2 >= len(r.data) {
+			return fmt.Errorf("truncated hex escape at offset %d", r.pos)
+		}
+		b, err := strconv.ParseUint(string(r.data[r.pos+1:r.pos+3]), 16, 8)
+		if err != nil {
+			return fmt.Errorf("invalid hex escape at offset %d: %w", r.pos, err)
+		}
+		r.pos += 3
+		if r.consumeSkip() || r.state.skip {
+			return nil
+		}
+		r.pending = append(r.pending, byte(b))
+		return nil
+
+	case isLetter(c):
+		return r.controlWord()
+
+	case c == '*':
+		r.pos++
+		r.state.skip = true
+		return nil
+
+	case c == '\r' || c == '\n':
+		r.pos++
+		r.flush()
+		r.emit("\n")
+		return nil
+
+	default:
+		r.pos++
+		if r.consumeSkip() {
+			return nil
+		}
+		r.flush()
+		switch c {
+		case '~':
+			r.emit("\u00A0")
+		case '_':
+			r.emit("-")
+		case '\\', '{', '}':
+			r.emit(string(c))
+		}
+		return nil
+	}
+}
+
+func (r *reader) controlWord() error {
+	start := r.pos
+	for r.pos < len(r.data) && isLetter(r.data[r.pos]) {
+		r.pos++
+	}
+	word := string(r.data[start:r.pos])
+
+	hasParam := false
+	param := 0
+	negative := false
+	if r.pos < len(r.data) && r.data[r.pos] == '-' {
+		negative = true
+		r.pos++
+	}
+	for r.pos < len(r.data) && r.data[r.pos] >= '0' && r.data[r.pos] <= '9' {
+		hasParam = true
+		param = param*10 + int(r.data[r.pos]-'0')
+		r.pos++
+	}
+	if negative {
+		param = -param
+	}
+	if r.pos < len(r.data) && r.data[r.pos] == ' ' {
+		r.pos++
+	}
+
+	switch word {
+	case "bin":
+		if hasParam && param > 0 {
+			r.pos += param
+		}
+		return nil
+	case "ansicpg":
+		if enc, ok := codePages[param]; ok {
+			r.decoder = enc
+		}
+		return nil
+	case "uc":
+		if hasParam {
+			r.state.ucSkip = param
+		}
+		return nil
+	case "u":
+		if !hasParam {
+			return nil
+		}
+		r.flush()
+		if param < 0 {
+			param += 0x10000
+		}
+		r.emitRune(rune(param))
+		r.toSkip = r.state.ucSkip
+		return nil
+	}
+
+	if skippedDestinations[word] {
+		r.state.skip = true
+		return nil
+	}
+
+	if r.consumeSkip() {
+		return nil
+	}
+	if symbol, ok := symbols[word]; ok {
+		r.flush()
+		r.emit(symbol)
+	}
+	return nil
+}
+
+// consumeSkip descarta um caractere de substituição pendente de um \uN.
+func (r *reader) consumeSkip() bool {
+	if r.toSkip > 0 {
+		r.toSkip--
+		return true
+	}
+	return false
+}
+
+func (r *reader) emitByte(c byte) {
+	if c < 0x80 {
+		r.emit(string(rune(c)))
+		return
+	}
+	r.pending = append(r.pending, c)
+	r.flush()
+}
+
+func (r *reader) emitRune(c rune) {
+	if r.state.skip {
+		return
+	}
+	if utf16.IsSurrogate(c) {
+		if r.highSurrogate != 0 {
+			r.out.WriteRune(utf16.DecodeRune(r.highSurrogate, c))
+			r.highSurrogate = 0
+			return
+		}
+		r.highSurrogate = c
+		return
+	}
+	r.highSurrogate = 0
+	r.out.WriteRune(c)
+}
+
+func (r *reader) emit(text string) {
+	if r.state.skip {
+		return
+	}
+	r.out.WriteString(text)
+}
+
+// flush decodifica os bytes acumulados de escapes \'hh usando a página de
+// código do documento.
+func (r *reader) flush() {
+	if len(r.pending) == 0 {
+		return
+	}
+	decoded, err := r.decoder.NewDecoder().Bytes(r.pending)
+	if err != nil {
+		decoded = r.pending
+	}
+	r.pending = r.pending[:0]
+	r.emit(string(decoded))
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,61 @@
+package rtf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestToText compara cada testdata/*.rtf com o .txt de mesmo nome.
+func TestToText(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.rtf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".rtf")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(fixture, ".rtf") + ".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ToText(data)
+			if err != nil {
+				t.Fatalf("ToText: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestToTextErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not rtf", "plain text"},
+		{"unterminated group", `{\rtf1 {\b text}`},
+		{"unbalanced group", `{\rtf1 text}}`},
+		{"truncated hex escape", `{\rtf1 \'e`},
+		{"invalid hex escape", `{\rtf1 \'zz}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ToText([]byte(tt.input)); err == nil {
+				t.Errorf("ToText(%q) succeeded, want an error", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"midweek-project/internal/agenda"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/locale"
+	"midweek-project/internal/store"
+	"midweek-project/internal/writer"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	congregationsPath = "data/congregations.json"
+	congregationsDir  = "data/congregations"
+)
+
+var ErrCongregationNotFound = errors.New("congregation not found")
+
+var congregations store.CongregationRepository = store.NewJSONCongregations(congregationsPath)
+
+// Cada congregação tem seu próprio store de publicadores e histórico; o store
+// de data/store.json continua valendo para quem não escolhe congregação.
+var (
+	repositoriesMu sync.Mutex
+	repositories   = map[string]store.Repository{}
+)
+
+func ListCongregations() ([]store.Congregation, error) {
+	return congregations.Congregations()
+}
+
+func GetCongregation(id string) (store.Congregation, error) {
+	c, err := congregations.Congregation(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return store.Congregation{}, ErrCongregationNotFound
+	}
+	return c, err
+}
+
+func SaveCongregation(c store.Congregation) error {
+	return congregations.SaveCongregation(c)
+}
+
+// DeleteCongregation remove só o perfil; publicadores e histórico ficam no
+// disco para o caso de o perfil ser recriado com o mesmo ID.
+func DeleteCongregation(id string) error {
+	err := congregations.DeleteCongregation(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrCongregationNotFound
+	}
+	return err
+}
+
+// repositoryFor devolve o store da congregação; ID vazio é o store padrão.
+func repositoryFor(id string) (store.Repository, error) {
+	if id == "" {
+		return repository, nil
+	}
+	if _, err := GetCongregation(id); err != nil {
+		return nil, err
+	}
+
+	repositoriesMu.Lock()
+	defer repositoriesMu.Unlock()
+
+	repo, ok := repositories[id]
+	if !ok {
+		repo = store.NewJSONRepository(filepath.Join(congregationsDir, id, "store.json"))
+		repositories[id] = repo
+	}
+	return repo, nil
+}
+
+// NewScheduleOptions monta as opções padrão da geração a partir do perfil da
+// congregação; perfil vazio dá as opções de sempre.
+func NewScheduleOptions(c store.Congregation) (ScheduleOptions, error) {
+	opts := ScheduleOptions{
+		SlipsPerPage: writer.SlipsPerPageFour,
+		Weights:      assigner.DefaultWeights,
+		Classrooms:   c.Classrooms,
+		Writer:       writer.DefaultOptions(),
+		Congregation: c,
+	}
+	opts.Writer.Congregation = c.Name
+	opts.Writer.HallNames = c.HallNames
+
+	if c.Locale != "" {
+		loc, err := locale.Get(c.Locale)
+		if err != nil {
+			return ScheduleOptions{}, err
+		}
+		opts.Writer.Locale = loc
+	}
+	if c.MeetingTime != "" {
+		start, err := agenda.ParseClock(c.MeetingTime)
+		if err != nil {
+			return ScheduleOptions{}, err
+		}
+		opts.Writer.MeetingStart = start
+	}
+	return opts, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/store"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// SwapAssignments troca as pessoas de duas vagas da última versão da
+// programação do período, gravando uma nova versão. O histórico do período e as
+// datas da planilha de designados são refeitos com a troca, e a alteração fica
+// registrada na auditoria.
+func SwapAssignments(congregationID, period string, first, second assigner.Target, by, reason string) ([]byte, error) {
+	return editSchedule(congregationID, period, func(slots []assigner.SlotAssignment) (store.AuditEntry, error) {
+		i, err := findAssignment(slots, first)
+		if err != nil {
+			return store.AuditEntry{}, err
+		}
+		j, err := findAssignment(slots, second)
+		if err != nil {
+			return store.AuditEntry{}, err
+		}
+		if i == j {
+			return store.AuditEntry{}, fmt.Errorf("%w: cannot swap a slot with itself", assigner.ErrInvalidTarget)
+		}
+
+		entry := store.AuditEntry{Action: store.AuditSwap, By: by, Reason: reason}
+		entry.Changes = append(entry.Changes, auditChange(slots[i], slots[j].Name), auditChange(slots[j], slots[i].Name))
+		slots[i].Name, slots[j].Name = slots[j].Name, slots[i].Name
+		return entry, nil
+	})
+}
+
+// SubstituteAssignment põe name no lugar de quem ocupa uma vaga da última
+// versão da programação do período, corrigindo histórico e planilha como em
+// SwapAssignments.
+func SubstituteAssignment(congregationID, period string, target assigner.Target, name, by, reason string) ([]byte, error) {
+	return editSchedule(congregationID, period, func(slots []assigner.SlotAssignment) (store.AuditEntry, error) {
+		i, err := findAssignment(slots, target)
+		if err != nil {
+			return store.AuditEntry{}, err
+		}
+
+		entry := store.AuditEntry{Action: store.AuditSubstitute, By: by, Reason: reason}
+		entry.Changes = append(entry.Changes, auditChange(slots[i], name))
+		slots[i].Name = name
+		return entry, nil
+	})
+}
+
+// ListAudit devolve as alterações manuais do período, da mais antiga para a
+// mais recente.
+func ListAudit(congregationID, period string) ([]store.AuditEntry, error) {
+	repo, err := repositoryFor(congregationID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := repo.AuditEntries()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]store.AuditEntry, 0)
+	for _, e := range entries {
+		if e.Period == period {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// editSchedule aplica edit às vagas da última versão da programação do período
+// e grava o resultado como uma nova versão, sem escolher ninguém de novo. A
+// planilha de designados parte da que foi recebida na geração, então as datas
+// de quem saiu de uma vaga voltam ao que eram e as de quem entrou são
+// regravadas. O histórico corrigido e a auditoria só chegam ao store depois
+// que a nova versão é guardada.
+func editSchedule(congregationID, period string, edit func(slots []assigner.SlotAssignment) (store.AuditEntry, error)) ([]byte, error) {
+	base, err := repositoryFor(congregationID)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := store.NewStagedRepository(base)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	saved, err := loadSchedule(congregationID, period)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := optionsFromManifest(saved.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := append([]assigner.SlotAssignment(nil), saved.Slots...)
+	entry, err := edit(slots)
+	if err != nil {
+		return nil, err
+	}
+
+	excelFile, err := excelize.OpenReader(bytes.NewReader(saved.Designates))
+	if err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	designatesPool, err := assigner.LoadAvailableDesignates(repo, period, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := assigner.ApplySlots(saved.Meetings, slots, designatesPool, repo, excelFile, period, assignerOptions(opts, rng))
+	if err != nil {
+		return nil, err
+	}
+	version, err := finishSchedule(period, opts, saved.Manifest.Year, saved.Designates, excelFile, result)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.At = time.Now().UTC()
+	entry.Period = period
+	entry.Version = version.Version
+	if _, err := repo.AddAuditEntry(entry); err != nil {
+		return nil, fmt.Errorf("failed to store audit entry: %w", err)
+	}
+	if err := repo.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to store history and audit entry: %w", err)
+	}
+	return version.Outputs, nil
+}
+
+// findAssignment localiza a vaga de target; a vaga padrão é a de titular.
+func findAssignment(slots []assigner.SlotAssignment, target assigner.Target) (int, error) {
+	slot := target.Slot
+	if slot == "" {
+		slot = store.RoleHolder
+	}
+	for i, a := range slots {
+		if a.Week == target.Week && a.Part == target.Part && a.Slot == slot {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: week %d has no %s slot for part %q", assigner.ErrInvalidTarget, target.Week, slot, assigner.PartID(target.Part))
+}
+
+func auditChange(a assigner.SlotAssignment, to string) store.AuditChange {
+	return store.AuditChange{Week: a.Week, Part: a.Part, Slot: a.Slot, From: a.Name, To: to}
+}
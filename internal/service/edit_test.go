@@ -0,0 +1,197 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/store"
+	"midweek-project/internal/writer"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const testPeriod = "2025-03"
+
+// testFunctions são as colunas da planilha de designados usadas nos testes,
+// com duas salas.
+var testFunctions = []string{
+	assigner.FUNC_PRESIDENTE, assigner.FUNC_CONSELHEIRO, assigner.FUNC_ORACAO,
+	assigner.FUNC_LEITOR_BIBLIA_A, assigner.FUNC_LEITOR_BIBLIA_B,
+	assigner.FUNC_DISCURSO_TESOUROS, assigner.FUNC_JOIAS, assigner.FUNC_DISCURSO_MINISTERIO,
+	assigner.FUNC_DISCURSO_CRISTA, assigner.FUNC_ESTUDO_BIBLICO, assigner.FUNC_LEITOR_ESTUDO,
+	assigner.FUNC_TITULAR_A_HOMEM, assigner.FUNC_AJUDANTE_A_HOMEM,
+	assigner.FUNC_TITULAR_A_MULHER, assigner.FUNC_AJUDANTE_A_MULHER,
+	assigner.FUNC_TITULAR_B_HOMEM, assigner.FUNC_AJUDANTE_B_HOMEM,
+	assigner.FUNC_TITULAR_B_MULHER, assigner.FUNC_AJUDANTE_B_MULHER,
+}
+
+func testWeek(startDay, endDay int, month string) string {
+	return fmt.Sprintf(`%d a %d de %s
+ISAÍAS 1-2
+Cântico 12 e oração | Comentários iniciais (1 min)
+TESOUROS DA PALAVRA DE DEUS
+1. Algo importante (10 min)
+2. Joias espirituais (10 min)
+3. Leitura da Bíblia (4 min) Is 1:1-10 (th lição 10)
+FAÇA SEU MELHOR NO MINISTÉRIO
+4. Iniciando conversas (3 min) DE CASA EM CASA. (lmd lição 1 ponto 3)
+5. Discurso (5 min) (th lição 7)
+NOSSA VIDA CRISTÃ
+Cântico 50
+6. Necessidades locais (15 min)
+7. Estudo bíblico de congregação (30 min)
+Comentários finais (3 min) | Cântico 100 e oração
+`, startDay, endDay, month)
+}
+
+// testDesignates monta a planilha de designados com 30 publicadores, cada um
+// em dois terços das funções.
+func testDesignates(t *testing.T) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "Publicadores")
+	for i, function := range testFunctions {
+		column, _ := excelize.ColumnNumberToName(2 + 2*i)
+		f.SetCellValue("Sheet1", column+"1", function)
+		last, _ := excelize.ColumnNumberToName(3 + 2*i)
+		f.SetCellValue("Sheet1", last+"1", "Última")
+	}
+	for row := 0; row < 30; row++ {
+		f.SetCellValue("Sheet1", fmt.Sprintf("A%d", row+2), fmt.Sprintf("Pub%02d", row))
+		for i := range testFunctions {
+			if (row+i)%3 != 0 {
+				column, _ := excelize.ColumnNumberToName(2 + 2*i)
+				f.SetCellValue("Sheet1", fmt.Sprintf("%s%d", column, row+2), "1")
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// setupSchedule gera a primeira versão de testPeriod num diretório temporário,
+// com store e programações próprios do teste.
+func setupSchedule(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	previous := repository
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		repository = previous
+		schedules = map[string]*store.JSONDocuments{}
+	})
+	repository = store.NewJSONRepository(storePath)
+	schedules = map[string]*store.JSONDocuments{}
+
+	dir := filepath.Join("data", "unzipped", testPeriod)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i, week := range []string{testWeek(3, 9, "março"), testWeek(10, 16, "março")} {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%02d.txt", i+1)), []byte(week), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := ScheduleOptions{SlipsPerPage: 4, Weights: assigner.DefaultWeights, Writer: writer.DefaultOptions(), Seed: 7}
+	if _, err := ProcessSchedule(bytes.NewReader(testDesignates(t)), testPeriod, opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubstituteAssignment(t *testing.T) {
+	setupSchedule(t)
+
+	target := assigner.Target{Week: 1, Part: assigner.FUNC_PRESIDENTE}
+	if _, err := SubstituteAssignment("", testPeriod, target, "Pub29", "Secretário", "doente"); err != nil {
+		t.Fatal(err)
+	}
+
+	audit, err := ListAudit("", testPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit) != 1 || audit[0].Version != 2 || audit[0].Changes[0].To != "Pub29" {
+		t.Fatalf("audit %+v, want one substitution to Pub29 in version 2", audit)
+	}
+
+	history, err := repository.Assignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, a := range history {
+		if a.Period == testPeriod && a.Part == assigner.FUNC_PRESIDENTE && a.Name == "Pub29" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("history does not have the substitute as chairman")
+	}
+}
+
+// TestEditKeepsHistoryWhenSaveFails confere que uma troca ou substituição cuja
+// versão não pôde ser guardada não mexe no histórico nem na auditoria.
+func TestEditKeepsHistoryWhenSaveFails(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func() error
+	}{
+		{"swap", func() error {
+			first := assigner.Target{Week: 1, Part: assigner.FUNC_PRESIDENTE}
+			second := assigner.Target{Week: 2, Part: assigner.FUNC_PRESIDENTE}
+			_, err := SwapAssignments("", testPeriod, first, second, "Secretário", "")
+			return err
+		}},
+		{"substitute", func() error {
+			target := assigner.Target{Week: 1, Part: assigner.FUNC_PRESIDENTE}
+			_, err := SubstituteAssignment("", testPeriod, target, "Pub29", "Secretário", "")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupSchedule(t)
+			before, err := os.ReadFile(storePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Um diretório no lugar do arquivo da versão 2 faz a gravação falhar.
+			blocker := filepath.Join(schedulesDir, testPeriod, "2.json", "blocker")
+			if err := os.MkdirAll(blocker, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(); err == nil {
+				t.Fatal("edit succeeded, want the version save to fail")
+			}
+
+			after, err := os.ReadFile(storePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(before, after) {
+				t.Error("store changed after a failed save")
+			}
+			versions, err := ListScheduleVersions("", testPeriod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(versions) != 1 {
+				t.Errorf("got %d versions, want 1", len(versions))
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"midweek-project/internal/agenda"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/locale"
+	"midweek-project/internal/store"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// RegenerateSchedule refaz só as vagas de target na última versão da
+// programação do período, com as mesmas opções e a mesma semente; as outras
+// designações continuam como estão e quem ocupava as vagas refeitas não volta
+// para elas. O resultado é guardado como uma nova versão, e o zip com os
+// documentos atualizados é devolvido.
+func RegenerateSchedule(congregationID, period string, target assigner.Target) ([]byte, error) {
+	base, err := repositoryFor(congregationID)
+	if err != nil {
+		return nil, err
+	}
+	// O histórico refeito só chega ao store depois que a nova versão é
+	// guardada.
+	repo, err := store.NewStagedRepository(base)
+	if err != nil {
+		return nil, err
+	}
+	defer repo.Rollback()
+
+	saved, err := loadSchedule(congregationID, period)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := optionsFromManifest(saved.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	excelFile, err := excelize.OpenReader(bytes.NewReader(saved.Designates))
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	designatesPool, err := assigner.LoadAvailableDesignates(repo, period, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := assigner.Reassign(saved.Meetings, saved.Slots, target, designatesPool, repo, excelFile, period, assignerOptions(opts, rng))
+	if err != nil {
+		return nil, err
+	}
+	version, err := finishSchedule(period, opts, saved.Manifest.Year, saved.Designates, excelFile, result)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to store history: %w", err)
+	}
+	return version.Outputs, nil
+}
+
+// optionsFromManifest refaz as opções de uma geração a partir do manifesto,
+// com o perfil atual da congregação para nomes de salas e da congregação.
+func optionsFromManifest(m manifest) (ScheduleOptions, error) {
+	var congregation store.Congregation
+	if m.Congregation != "" {
+		c, err := GetCongregation(m.Congregation)
+		if err != nil {
+			return ScheduleOptions{}, err
+		}
+		congregation = c
+	}
+	opts, err := NewScheduleOptions(congregation)
+	if err != nil {
+		return ScheduleOptions{}, err
+	}
+
+	opts.Seed = m.Seed
+	opts.SlipsPerPage = m.SlipsPerPage
+	opts.Weights = m.Weights
+	opts.Solver = m.Solver
+	opts.Strict = m.Strict
+	opts.Classrooms = m.Classrooms
+	opts.Year = m.Year
+	opts.Pins = m.Pins
+	opts.SolverIterations = m.SolverIterations
+	if m.MeetingStart != "" {
+		if opts.Writer.MeetingStart, err = agenda.ParseClock(m.MeetingStart); err != nil {
+			return ScheduleOptions{}, err
+		}
+	}
+	if m.Locale != "" {
+		if opts.Writer.Locale, err = locale.Get(m.Locale); err != nil {
+			return ScheduleOptions{}, err
+		}
+	}
+	opts.Writer.Classrooms = opts.Classrooms
+	return opts, nil
+}
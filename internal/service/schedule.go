@@ -4,24 +4,90 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"midweek-project/internal/agenda"
 	"midweek-project/internal/assigner"
+	"midweek-project/internal/epub"
+	"midweek-project/internal/locale"
 	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
 	"midweek-project/internal/util"
 	"midweek-project/internal/writer"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
 const (
 	zipInputPath = "data/unzipped"
+	storePath    = "data/store.json"
 )
 
+var repository store.Repository = store.NewJSONRepository(storePath)
+
+var (
+	reWorkbookDate = regexp.MustCompile(`(?:^|\D)(20\d{2})[-_.]?(0[1-9]|1[0-2])(?:\D|$)`)
+	rePeriodYear   = regexp.MustCompile(`(?:^|\D)(20\d{2})(?:\D|$)`)
+)
+
+type ScheduleOptions struct {
+	SlipsPerPage int
+	Weights      assigner.Weights
+	Solver       bool
+	// SolverIterations é o número de passos do otimizador; zero vale o padrão.
+	SolverIterations int
+	Seed             int64
+	Strict           bool
+	// Classrooms é o número de salas com partes de estudante, de 1 a 3.
+	Classrooms int
+	// Year fixa o ano das semanas; zero deixa que ele seja inferido dos nomes
+	// dos arquivos da apostila ou do período.
+	Year   int
+	Writer writer.Options
+	// WeekTypes fixa o tipo de semanas específicas e vence a aba "Semanas" da
+	// planilha e o que foi detectado na apostila.
+	WeekTypes []parser.WeekOverride
+	// Congregation é o perfil escolhido; define o store usado, o dia da
+	// reunião e os documentos incluídos no zip.
+	Congregation store.Congregation
+	// Pins são designações já decididas que a geração respeita.
+	Pins []assigner.Pin
+}
+
+// manifest registra, dentro do zip, tudo o que é preciso para gerar a mesma
+// programação de novo.
+type manifest struct {
+	Period       string           `json:"period"`
+	Version      int              `json:"version"`
+	Seed         int64            `json:"seed"`
+	GeneratedAt  time.Time        `json:"generated_at"`
+	SlipsPerPage int              `json:"slips_per_page"`
+	Weights      assigner.Weights `json:"weights"`
+	Solver       bool             `json:"solver"`
+	// SolverIterations é o número de passos que o otimizador deu, para que a
+	// semente refaça a mesma escala.
+	SolverIterations int    `json:"solver_iterations,omitempty"`
+	Strict           bool   `json:"strict"`
+	Classrooms       int    `json:"classrooms"`
+	Year             int    `json:"year"`
+	Congregation     string `json:"congregation,omitempty"`
+	MeetingStart     string `json:"meeting_start"`
+	Locale           string `json:"locale"`
+	// WeekTypes traz, pelo primeiro dia, as semanas que não seguem a programação normal.
+	WeekTypes map[string]parser.WeekType `json:"week_types,omitempty"`
+	Pins      []assigner.Pin             `json:"pins,omitempty"`
+}
+
 func ListZipFiles(ctx context.Context) ([]string, error) {
 	var files []string
 
@@ -67,6 +133,14 @@ func StoreZipFile(file multipart.File, filename string) error {
 		return fmt.Errorf("failed to create dest dir: %w", err)
 	}
 
+	if epub.IsEPUB(tempZipPath) {
+		if err := storeEPUBChapters(tempZipPath, destDir); err != nil {
+			return err
+		}
+		_ = os.Remove(tempZipPath)
+		return nil
+	}
+
 	rtfPaths, err := util.UnzipRTFFiles(tempZipPath, destDir)
 	if err != nil {
 		return fmt.Errorf("failed to unzip: %w", err)
@@ -87,69 +161,304 @@ func StoreZipFile(file multipart.File, filename string) error {
 	return nil
 }
 
-func ProcessSchedule(designates io.Reader, period string) ([]byte, error) {
+// storeEPUBChapters grava como .txt apenas os capítulos do EPUB que trazem uma
+// semana da apostila, prontos para o parser.
+func storeEPUBChapters(epubPath, destDir string) error {
+	chapters, err := epub.ReadChapters(epubPath)
+	if err != nil {
+		return fmt.Errorf("failed to read epub: %w", err)
+	}
+
+	stored := 0
+	for _, chapter := range chapters {
+		if !parser.IsMeetingContent(chapter.Text) {
+			continue
+		}
+		base := strings.TrimSuffix(filepath.Base(chapter.Name), filepath.Ext(chapter.Name))
+		outputPath := filepath.Join(destDir, fmt.Sprintf("%02d_%s.txt", stored+1, base))
+		if err := os.WriteFile(outputPath, []byte(chapter.Text), 0o644); err != nil {
+			return fmt.Errorf("failed to write chapter %s: %w", chapter.Name, err)
+		}
+		stored++
+	}
+
+	if stored == 0 {
+		return fmt.Errorf("no meeting weeks found in epub")
+	}
+	return nil
+}
+
+// ProcessSchedule gera a programação do período e devolve o zip com os
+// documentos.
+func ProcessSchedule(designates io.Reader, period string, opts ScheduleOptions) ([]byte, error) {
+	saved, err := generateSchedule(designates, period, opts)
+	if err != nil {
+		return nil, err
+	}
+	return saved.Outputs, nil
+}
+
+// ProcessScheduleJSON gera a programação do período como ProcessSchedule, mas
+// devolve as reuniões no formato JSON de Schedule.
+func ProcessScheduleJSON(designates io.Reader, period string, opts ScheduleOptions) (Schedule, error) {
+	saved, err := generateSchedule(designates, period, opts)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return scheduleView(saved), nil
+}
+
+// generateSchedule gera a programação do período e a guarda como a próxima
+// versão.
+func generateSchedule(designates io.Reader, period string, opts ScheduleOptions) (storedSchedule, error) {
+	if err := checkPeriod(period); err != nil {
+		return storedSchedule{}, err
+	}
+
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, designates); err != nil {
-		return nil, err
+		return storedSchedule{}, err
 	}
+	input := append([]byte(nil), buf.Bytes()...)
 
 	excelFile, err := excelize.OpenReader(&buf)
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
 	}
 
-	designatesPool, err := assigner.LoadAvailableDesignatesFromFile(excelFile)
+	base, err := repositoryFor(opts.Congregation.ID)
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
+	}
+	// A importação e o histórico da geração só chegam ao store depois que a
+	// versão é guardada; uma geração estrita com conflitos não deixa rastro.
+	repo, err := store.NewStagedRepository(base)
+	if err != nil {
+		return storedSchedule{}, err
+	}
+	defer repo.Rollback()
+
+	if err := assigner.ImportDesignatesFromFile(excelFile, repo); err != nil {
+		return storedSchedule{}, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	designatesPool, err := assigner.LoadAvailableDesignates(repo, period, rng)
+	if err != nil {
+		return storedSchedule{}, err
 	}
 
 	txtPaths, err := util.ListTxtFilesForPeriod(period)
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
 	}
 
 	txtContents, err := util.ReadTxtFiles(txtPaths)
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
 	}
 
-	meetings, err := parser.ParseAllMeetings(txtContents)
+	dateRef := resolveDateReference(period, txtPaths, opts.Year)
+
+	opts.Writer.Classrooms = opts.Classrooms
+	if opts.Writer.Locale == nil {
+		opts.Writer.Locale = locale.MustGet(locale.Default)
+	}
+	meetings, err := parser.ParseAllMeetings(txtContents, dateRef, opts.Writer.Locale)
+	if errors.Is(err, parser.ErrUnknownYear) {
+		return storedSchedule{}, fmt.Errorf("%w: unable to infer it from period %s or the workbook files", err, period)
+	}
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
+	}
+
+	if opts.Congregation.MeetingDay != "" {
+		day, err := agenda.ParseWeekday(opts.Congregation.MeetingDay)
+		if err != nil {
+			return storedSchedule{}, err
+		}
+		parser.SetMeetingDay(meetings, day)
 	}
 
-	meetingsWithDesignates, err := assigner.AssignToMeetings(meetings, designatesPool, excelFile)
+	sheetWeekTypes, err := assigner.ReadWeekTypes(excelFile)
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
 	}
+	parser.ApplyWeekTypes(meetings, append(sheetWeekTypes, opts.WeekTypes...))
 
-	docContent, err := writer.GenerateDesignationsDoc(meetingsWithDesignates, period)
+	result, err := assigner.AssignToMeetings(meetings, designatesPool, repo, excelFile, period, assignerOptions(opts, rng))
 	if err != nil {
-		return nil, err
+		return storedSchedule{}, err
+	}
+	saved, err := finishSchedule(period, opts, dateRef.Year, input, excelFile, result)
+	if err != nil {
+		return storedSchedule{}, err
+	}
+	if err := repo.Commit(); err != nil {
+		return storedSchedule{}, fmt.Errorf("failed to store imported designates and history: %w", err)
+	}
+	return saved, nil
+}
+
+func assignerOptions(opts ScheduleOptions, rng *rand.Rand) assigner.Options {
+	return assigner.Options{
+		Weights:          opts.Weights,
+		Solver:           opts.Solver,
+		SolverIterations: opts.SolverIterations,
+		Rand:             rng,
+		Strict:           opts.Strict,
+		Classrooms:       opts.Classrooms,
+		Pins:             opts.Pins,
+	}
+}
+
+// finishSchedule monta o zip com os documentos e guarda a programação gerada
+// como a próxima versão do período. input é a planilha de designados recebida,
+// antes das datas desta geração.
+func finishSchedule(period string, opts ScheduleOptions, year int, input []byte, excelFile *excelize.File, result *assigner.Result) (storedSchedule, error) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+
+	version, err := nextVersion(opts.Congregation.ID, period)
+	if err != nil {
+		return storedSchedule{}, err
+	}
+
+	meetingsWithDesignates := result.Meetings
+	m := newManifest(period, opts, year, meetingsWithDesignates)
+	m.Version = version
+
+	decisions, err := json.MarshalIndent(result.Decisions, "", "  ")
+	if err != nil {
+		return storedSchedule{}, err
+	}
+
+	report, err := json.MarshalIndent(map[string][]assigner.Conflict{"conflicts": result.Conflicts}, "", "  ")
+	if err != nil {
+		return storedSchedule{}, err
+	}
+
+	manifestContent, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return storedSchedule{}, err
+	}
+
+	docContent, err := writer.GenerateDesignationsDoc(meetingsWithDesignates, period, opts.SlipsPerPage, opts.Writer)
+	if err != nil {
+		return storedSchedule{}, err
 	}
 
 	var midweekBuffer bytes.Buffer
-	if err := writer.WriteToBuffer(meetingsWithDesignates, &midweekBuffer); err != nil {
-		return nil, err
+	if err := writer.WriteToBuffer(meetingsWithDesignates, &midweekBuffer, opts.Writer); err != nil {
+		return storedSchedule{}, err
 	}
 
 	var designatesBuffer bytes.Buffer
 	if err := excelFile.Write(&designatesBuffer); err != nil {
-		return nil, err
+		return storedSchedule{}, err
 	}
 
 	var zipBuffer bytes.Buffer
 	zipWriter := zip.NewWriter(&zipBuffer)
 
-	writeToZip(zipWriter, fmt.Sprintf("%s.xlsx", period), midweekBuffer.Bytes())
-	writeToZip(zipWriter, "designates.xlsx", designatesBuffer.Bytes())
-	writeToZip(zipWriter, fmt.Sprintf("%s.docx", period), docContent)
+	if opts.Congregation.Includes(store.OutputSchedule) {
+		writeToZip(zipWriter, fmt.Sprintf("%s.xlsx", period), midweekBuffer.Bytes())
+	}
+	if opts.Congregation.Includes(store.OutputDesignates) {
+		writeToZip(zipWriter, "designates.xlsx", designatesBuffer.Bytes())
+	}
+	if opts.Congregation.Includes(store.OutputSlips) {
+		writeToZip(zipWriter, fmt.Sprintf("%s.docx", period), docContent)
+	}
+	writeToZip(zipWriter, "decisions.json", decisions)
+	writeToZip(zipWriter, "report.json", report)
+	writeToZip(zipWriter, "manifest.json", manifestContent)
 
 	if err := zipWriter.Close(); err != nil {
-		return nil, err
+		return storedSchedule{}, err
+	}
+
+	saved := storedSchedule{
+		Version:    version,
+		Manifest:   m,
+		Meetings:   meetingsWithDesignates,
+		Slots:      result.Slots,
+		Conflicts:  result.Conflicts,
+		Designates: input,
+		Outputs:    zipBuffer.Bytes(),
+	}
+	if err := saveVersion(opts.Congregation.ID, saved); err != nil {
+		return storedSchedule{}, fmt.Errorf("failed to store schedule: %w", err)
+	}
+	return saved, nil
+}
+
+func newManifest(period string, opts ScheduleOptions, year int, meetings []parser.MeetingData) manifest {
+	m := manifest{
+		Period:       period,
+		Seed:         opts.Seed,
+		GeneratedAt:  time.Now().UTC(),
+		SlipsPerPage: opts.SlipsPerPage,
+		Weights:      opts.Weights,
+		Solver:       opts.Solver,
+		Strict:       opts.Strict,
+		Classrooms:   len(assigner.Halls(opts.Classrooms)),
+		Year:         year,
+		Congregation: opts.Congregation.ID,
+		MeetingStart: agenda.FormatClock(opts.Writer.MeetingStart),
+		Locale:       opts.Writer.Locale.Code,
+		Pins:         opts.Pins,
+	}
+	for _, meeting := range meetings {
+		if meeting.Type == parser.WeekRegular {
+			continue
+		}
+		if m.WeekTypes == nil {
+			m.WeekTypes = make(map[string]parser.WeekType)
+		}
+		m.WeekTypes[meeting.StartDate.Format("2006-01-02")] = meeting.Type
+	}
+	if opts.Solver {
+		m.SolverIterations = opts.SolverIterations
+		if m.SolverIterations <= 0 {
+			m.SolverIterations = assigner.DefaultSolverIterations
+		}
 	}
+	return m
+}
 
-	return zipBuffer.Bytes(), nil
+// resolveDateReference decide o ano das semanas: o informado na requisição, ou
+// o ano e mês que os arquivos da apostila trazem no nome (mwb_T_202601_01.rtf),
+// ou o ano no nome do período. Sem nenhum deles, só as semanas que trazem o
+// ano na data podem ser lidas.
+func resolveDateReference(period string, txtPaths []string, year int) parser.DateReference {
+	var ref parser.DateReference
+	names := []string{period}
+	for _, path := range txtPaths {
+		names = append(names, filepath.Base(path))
+	}
+	for _, name := range names {
+		if match := reWorkbookDate.FindStringSubmatch(name); match != nil {
+			ref.Year, _ = strconv.Atoi(match[1])
+			month, _ := strconv.Atoi(match[2])
+			ref.Month = time.Month(month)
+			break
+		}
+	}
+	if ref.Year == 0 {
+		if match := rePeriodYear.FindStringSubmatch(period); match != nil {
+			ref.Year, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	if year != 0 {
+		if ref.Year != year {
+			ref.Month = 0
+		}
+		ref.Year = year
+	}
+	return ref
 }
 
 func writeToZip(zipWriter *zip.Writer, filename string, data []byte) {
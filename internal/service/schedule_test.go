@@ -0,0 +1,27 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/writer"
+	"testing"
+)
+
+func TestProcessScheduleInvalidPin(t *testing.T) {
+	setupSchedule(t)
+
+	pins := []assigner.Pin{{Week: 5, Part: assigner.FUNC_PRESIDENTE, Name: "Pub01"}}
+	opts := ScheduleOptions{SlipsPerPage: 4, Weights: assigner.DefaultWeights, Writer: writer.DefaultOptions(), Seed: 7, Pins: pins}
+	if _, err := ProcessSchedule(bytes.NewReader(testDesignates(t)), testPeriod, opts); !errors.Is(err, assigner.ErrInvalidPin) {
+		t.Fatalf("got %v, want ErrInvalidPin", err)
+	}
+
+	versions, err := ListScheduleVersions("", testPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Errorf("got %d versions, want 1", len(versions))
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"strings"
+	"time"
+)
+
+// Este arquivo define o formato JSON estável das programações, devolvido por
+// GET /schedules/{period} e por POST /generate-schedule com format=json. Os
+// campos, os identificadores e um exemplo estão em docs/schedules.md.
+const dateLayout = "2006-01-02"
+
+// Schedule é uma versão da programação de um período.
+type Schedule struct {
+	Period      string     `json:"period"`
+	Version     int        `json:"version"`
+	Published   bool       `json:"published"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Seed        int64      `json:"seed"`
+	Locale      string     `json:"locale"`
+	Meetings    []Meeting  `json:"meetings"`
+	Conflicts   []Conflict `json:"conflicts"`
+}
+
+// Meeting é uma semana do período. Week é a posição da semana, a partir de 1;
+// Date é o dia da reunião e fica vazio se o dia da congregação não for
+// conhecido.
+type Meeting struct {
+	Week          int          `json:"week"`
+	Label         string       `json:"label"`
+	Type          string       `json:"type"`
+	StartDate     string       `json:"start_date"`
+	EndDate       string       `json:"end_date"`
+	Date          string       `json:"date,omitempty"`
+	Songs         Songs        `json:"songs"`
+	Chairman      *Assignment  `json:"chairman,omitempty"`
+	Counselors    []Assignment `json:"counselors"`
+	OpeningPrayer *Assignment  `json:"opening_prayer,omitempty"`
+	ClosingPrayer *Assignment  `json:"closing_prayer,omitempty"`
+	Sections      []Section    `json:"sections"`
+}
+
+// Songs traz os cânticos como aparecem na apostila.
+type Songs struct {
+	Opening string `json:"opening"`
+	Middle  string `json:"middle"`
+	Closing string `json:"closing"`
+}
+
+// Section é uma seção da reunião: treasures, ministry ou christian_living.
+type Section struct {
+	ID    string `json:"id"`
+	Parts []Part `json:"parts"`
+}
+
+// Part é uma parte numerada da apostila com as designações de todas as salas.
+type Part struct {
+	Number         int          `json:"number"`
+	Key            string       `json:"key"`
+	Title          string       `json:"title"`
+	Minutes        int          `json:"minutes"`
+	Kind           string       `json:"kind"`
+	Description    string       `json:"description,omitempty"`
+	StudyReference string       `json:"study_reference,omitempty"`
+	StudyPoint     int          `json:"study_point,omitempty"`
+	Assignments    []Assignment `json:"assignments"`
+}
+
+// Assignment é uma vaga da programação. Role é holder ou assistant; Hall é a
+// sala (A, B ou C) e fica vazio nas vagas que não dependem de sala.
+type Assignment struct {
+	Part string `json:"part"`
+	Role string `json:"role"`
+	Hall string `json:"hall,omitempty"`
+	Name string `json:"name"`
+}
+
+// Conflict é um conflito da escala. Week é a posição da semana, como em
+// Meeting, e Label o rótulo dela; Part, Role e Hall identificam a vaga como numa
+// Assignment. Function é a coluna da planilha de designados, só para leitura.
+type Conflict struct {
+	Kind     string `json:"kind"`
+	Week     int    `json:"week,omitempty"`
+	Label    string `json:"label,omitempty"`
+	Part     string `json:"part,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Hall     string `json:"hall,omitempty"`
+	Function string `json:"function,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Message  string `json:"message"`
+}
+
+// scheduleView converte uma versão guardada para o formato JSON.
+func scheduleView(s storedSchedule) Schedule {
+	byWeek := make(map[int][]assigner.SlotAssignment)
+	for _, a := range s.Slots {
+		byWeek[a.Week] = append(byWeek[a.Week], a)
+	}
+
+	meetings := make([]Meeting, 0, len(s.Meetings))
+	weeks := make(map[string]int, len(s.Meetings))
+	for i, m := range s.Meetings {
+		meetings = append(meetings, meetingView(i+1, m, byWeek[i+1]))
+		if _, ok := weeks[m.MeetingDate]; !ok {
+			weeks[m.MeetingDate] = i + 1
+		}
+	}
+
+	conflicts := make([]Conflict, 0, len(s.Conflicts))
+	for _, c := range s.Conflicts {
+		conflicts = append(conflicts, conflictView(c, weeks))
+	}
+	return Schedule{
+		Period:      s.Manifest.Period,
+		Version:     s.Version,
+		Published:   s.Published,
+		GeneratedAt: s.Manifest.GeneratedAt,
+		Seed:        s.Manifest.Seed,
+		Locale:      s.Manifest.Locale,
+		Meetings:    meetings,
+		Conflicts:   conflicts,
+	}
+}
+
+func meetingView(week int, m parser.MeetingData, slots []assigner.SlotAssignment) Meeting {
+	meeting := Meeting{
+		Week:       week,
+		Label:      m.MeetingDate,
+		Type:       string(m.Type),
+		StartDate:  formatDate(m.StartDate),
+		EndDate:    formatDate(m.EndDate),
+		Date:       formatDate(m.Date),
+		Songs:      Songs{Opening: m.InitSong, Middle: m.MidSong, Closing: m.FinalSong},
+		Counselors: []Assignment{},
+		Sections:   make([]Section, 0, len(parser.Sections)),
+	}
+	if meeting.Type == "" {
+		meeting.Type = string(parser.WeekRegular)
+	}
+
+	for _, a := range slots {
+		assignment := assignmentView(a)
+		switch a.Part {
+		case assigner.FUNC_PRESIDENTE:
+			meeting.Chairman = &assignment
+		case assigner.FUNC_CONSELHEIRO, assigner.FUNC_CONSELHEIRO_C:
+			meeting.Counselors = append(meeting.Counselors, assignment)
+		case assigner.FUNC_ORACAO:
+			meeting.OpeningPrayer = &assignment
+		case assigner.FUNC_ORACAO_FINAL:
+			meeting.ClosingPrayer = &assignment
+		}
+	}
+
+	for _, section := range parser.Sections {
+		parts := make([]Part, 0)
+		for _, p := range m.PartsIn(section) {
+			parts = append(parts, partView(p, slots))
+		}
+		meeting.Sections = append(meeting.Sections, Section{ID: string(section), Parts: parts})
+	}
+	return meeting
+}
+
+// partView junta à parte as vagas da chave dela, inclusive as de cada sala
+// ("4.A", "4.B").
+func partView(p parser.Part, slots []assigner.SlotAssignment) Part {
+	part := Part{
+		Number:         p.Number,
+		Key:            p.Key(),
+		Title:          p.Title,
+		Minutes:        p.Minutes,
+		Kind:           string(p.Kind),
+		Description:    p.Description,
+		StudyReference: p.StudyReference,
+		StudyPoint:     p.StudyPoint,
+		Assignments:    []Assignment{},
+	}
+	for _, a := range slots {
+		if a.Part == part.Key || strings.HasPrefix(a.Part, part.Key+".") {
+			part.Assignments = append(part.Assignments, assignmentView(a))
+		}
+	}
+	return part
+}
+
+func assignmentView(a assigner.SlotAssignment) Assignment {
+	role := a.Slot
+	if role == "" {
+		role = store.RoleHolder
+	}
+	return Assignment{Part: assigner.PartID(a.Part), Role: role, Hall: a.Hall, Name: a.Name}
+}
+
+// conflictView converte um conflito guardado, que traz só o rótulo da semana;
+// weeks liga cada rótulo à posição da semana.
+func conflictView(c assigner.Conflict, weeks map[string]int) Conflict {
+	return Conflict{
+		Kind:     c.Kind,
+		Week:     weeks[c.Week],
+		Label:    c.Week,
+		Part:     assigner.PartID(c.Part),
+		Role:     c.Role,
+		Hall:     c.Hall,
+		Function: c.Function,
+		Name:     c.Name,
+		Message:  c.Message,
+	}
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(dateLayout)
+}
+
+// GetSchedule devolve a programação do período no formato JSON: a versão
+// pedida ou, com version zero, a publicada, e na falta dela a mais recente.
+func GetSchedule(congregationID, period string, version int) (Schedule, error) {
+	if version > 0 {
+		s, err := loadVersion(congregationID, period, version)
+		if err != nil {
+			return Schedule{}, err
+		}
+		return scheduleView(s), nil
+	}
+
+	index, err := loadIndex(congregationID, period)
+	if err != nil {
+		return Schedule{}, err
+	}
+	chosen := index.Published
+	if chosen == 0 {
+		chosen = index.Latest
+	}
+	if chosen == 0 {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	s, err := loadVersion(congregationID, period, chosen)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return scheduleView(s), nil
+}
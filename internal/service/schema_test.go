@@ -0,0 +1,34 @@
+package service
+
+import (
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"testing"
+)
+
+func TestScheduleViewConflicts(t *testing.T) {
+	s := storedSchedule{
+		Meetings: []parser.MeetingData{{MeetingDate: "3 a 9 de março"}, {MeetingDate: "10 a 16 de março"}},
+		Conflicts: []assigner.Conflict{
+			{Kind: assigner.ConflictUnfilled, Week: "10 a 16 de março", Part: "4.B", Role: store.RoleAssistant, Hall: store.HallAuxiliary, Function: assigner.FUNC_AJUDANTE_B},
+			{Kind: assigner.ConflictIneligible, Week: "3 a 9 de março", Part: assigner.FUNC_PRESIDENTE, Role: store.RoleHolder, Name: "Pub01"},
+			{Kind: assigner.ConflictMissingFunction, Function: assigner.FUNC_LEITOR_BIBLIA_C},
+		},
+	}
+
+	want := []Conflict{
+		{Kind: assigner.ConflictUnfilled, Week: 2, Label: "10 a 16 de março", Part: "4.B", Role: store.RoleAssistant, Hall: store.HallAuxiliary, Function: assigner.FUNC_AJUDANTE_B},
+		{Kind: assigner.ConflictIneligible, Week: 1, Label: "3 a 9 de março", Part: "chairman", Role: store.RoleHolder, Name: "Pub01"},
+		{Kind: assigner.ConflictMissingFunction, Function: assigner.FUNC_LEITOR_BIBLIA_C},
+	}
+	got := scheduleView(s).Conflicts
+	if len(got) != len(want) {
+		t.Fatalf("got %d conflicts, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("conflict %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"midweek-project/internal/store"
+	"sort"
+	"time"
+)
+
+func ListUnavailabilities(congregation string) ([]store.Unavailability, error) {
+	repo, err := repositoryFor(congregation)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := repo.Unavailabilities()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].From.Equal(entries[j].From) {
+			return entries[i].From.Before(entries[j].From)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+func CreateUnavailability(congregation, name string, from, to time.Time, reason string) (store.Unavailability, error) {
+	repo, err := repositoryFor(congregation)
+	if err != nil {
+		return store.Unavailability{}, err
+	}
+	return repo.AddUnavailability(store.Unavailability{
+		Name:   name,
+		From:   from,
+		To:     to,
+		Reason: reason,
+		Source: store.SourceAPI,
+	})
+}
+
+func DeleteUnavailability(congregation, id string) error {
+	repo, err := repositoryFor(congregation)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteUnavailability(id)
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/parser"
+	"midweek-project/internal/store"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const schedulesDir = "data/schedules"
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrInvalidPeriod    = errors.New("invalid period")
+)
+
+// Cada geração de um período vira uma versão numerada a partir de 1, guardada
+// em <dir>/<período>/<versão>.json junto do store da congregação, com um
+// índice em <dir>/<período>/index.json. Regerar, trocar ou substituir parte da
+// versão mais recente e cria a seguinte.
+var (
+	schedulesMu sync.Mutex
+	schedules   = map[string]*store.JSONDocuments{}
+	// versionsMu serializa a numeração e a publicação das versões.
+	versionsMu sync.Mutex
+)
+
+// storedSchedule é uma versão gerada da programação de um período, com o que é
+// preciso para refazer uma semana ou vaga sem mexer no resto e para baixar de
+// novo os documentos entregues.
+type storedSchedule struct {
+	Version     int                       `json:"version"`
+	Published   bool                      `json:"published"`
+	PublishedAt *time.Time                `json:"published_at,omitempty"`
+	Manifest    manifest                  `json:"manifest"`
+	Meetings    []parser.MeetingData      `json:"meetings"`
+	Slots       []assigner.SlotAssignment `json:"slots"`
+	Conflicts   []assigner.Conflict       `json:"conflicts"`
+	// Designates é a planilha de designados recebida, antes das datas desta
+	// geração.
+	Designates []byte `json:"designates"`
+	// Outputs é o zip entregue na geração.
+	Outputs []byte `json:"outputs"`
+}
+
+// SchedulePeriod resume as versões guardadas de um período. Published é zero
+// se nenhuma versão foi publicada.
+type SchedulePeriod struct {
+	Period    string `json:"period"`
+	Versions  int    `json:"versions"`
+	Latest    int    `json:"latest"`
+	Published int    `json:"published,omitempty"`
+}
+
+// ScheduleVersion resume uma versão guardada.
+type ScheduleVersion struct {
+	Period        string     `json:"period"`
+	Version       int        `json:"version"`
+	GeneratedAt   time.Time  `json:"generated_at"`
+	Seed          int64      `json:"seed"`
+	ConflictCount int        `json:"conflict_count"`
+	Published     bool       `json:"published"`
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+}
+
+// ScheduleVersionDetail é a versão com as reuniões designadas, as vagas, os
+// conflitos e a configuração usada na geração.
+type ScheduleVersionDetail struct {
+	ScheduleVersion
+	Manifest  manifest                  `json:"manifest"`
+	Meetings  []parser.MeetingData      `json:"meetings"`
+	Slots     []assigner.SlotAssignment `json:"slots"`
+	Conflicts []assigner.Conflict       `json:"conflicts"`
+}
+
+const indexKey = "index"
+
+// periodIndex resume as versões de um período, para listar e escolher uma
+// versão sem abrir os documentos, que trazem a planilha e o zip.
+type periodIndex struct {
+	Latest    int               `json:"latest"`
+	Published int               `json:"published,omitempty"`
+	Versions  []ScheduleVersion `json:"versions"`
+}
+
+// put grava o resumo da versão no índice, no lugar do anterior se houver.
+func (idx *periodIndex) put(v ScheduleVersion) {
+	replaced := false
+	for i := range idx.Versions {
+		if idx.Versions[i].Version == v.Version {
+			idx.Versions[i] = v
+			replaced = true
+		}
+	}
+	if !replaced {
+		idx.Versions = append(idx.Versions, v)
+		sort.Slice(idx.Versions, func(i, j int) bool {
+			return idx.Versions[i].Version < idx.Versions[j].Version
+		})
+	}
+	if v.Version > idx.Latest {
+		idx.Latest = v.Version
+	}
+	if v.Published {
+		idx.Published = v.Version
+	} else if idx.Published == v.Version {
+		idx.Published = 0
+	}
+}
+
+func (idx periodIndex) has(version int) bool {
+	for _, v := range idx.Versions {
+		if v.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (s storedSchedule) summary() ScheduleVersion {
+	return ScheduleVersion{
+		Period:        s.Manifest.Period,
+		Version:       s.Version,
+		GeneratedAt:   s.Manifest.GeneratedAt,
+		Seed:          s.Manifest.Seed,
+		ConflictCount: len(s.Conflicts),
+		Published:     s.Published,
+		PublishedAt:   s.PublishedAt,
+	}
+}
+
+// schedulesRoot devolve o diretório das programações da congregação; ID vazio
+// é o diretório padrão.
+func schedulesRoot(congregationID string) (string, error) {
+	if congregationID == "" {
+		return schedulesDir, nil
+	}
+	if _, err := GetCongregation(congregationID); err != nil {
+		return "", err
+	}
+	return filepath.Join(congregationsDir, congregationID, "schedules"), nil
+}
+
+// checkPeriod recusa períodos que não servem de nome de diretório.
+func checkPeriod(period string) error {
+	if period == "" || period == "." || period == ".." || strings.ContainsAny(period, `/\`) {
+		return fmt.Errorf("%w %q", ErrInvalidPeriod, period)
+	}
+	return nil
+}
+
+func versionsFor(congregationID, period string) (*store.JSONDocuments, error) {
+	if err := checkPeriod(period); err != nil {
+		return nil, err
+	}
+	root, err := schedulesRoot(congregationID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedulesMu.Lock()
+	defer schedulesMu.Unlock()
+
+	dir := filepath.Join(root, period)
+	docs, ok := schedules[dir]
+	if !ok {
+		docs = store.NewJSONDocuments(dir)
+		schedules[dir] = docs
+	}
+	return docs, nil
+}
+
+// versionNumbers devolve as versões guardadas do período em ordem crescente.
+func versionNumbers(docs *store.JSONDocuments) ([]int, error) {
+	keys, err := docs.Keys()
+	if err != nil {
+		return nil, err
+	}
+	var versions []int
+	for _, key := range keys {
+		if version, err := strconv.Atoi(key); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// nextVersion é o número da próxima versão do período; quem chama segura
+// versionsMu até gravá-la.
+func nextVersion(congregationID, period string) (int, error) {
+	docs, err := versionsFor(congregationID, period)
+	if err != nil {
+		return 0, err
+	}
+	versions, err := versionNumbers(docs)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 1, nil
+	}
+	return versions[len(versions)-1] + 1, nil
+}
+
+// loadIndex lê o índice do período. Períodos gravados antes do índice têm o
+// índice refeito a partir das versões na primeira leitura.
+func loadIndex(congregationID, period string) (periodIndex, error) {
+	docs, err := versionsFor(congregationID, period)
+	if err != nil {
+		return periodIndex{}, err
+	}
+	var index periodIndex
+	err = docs.Load(indexKey, &index)
+	if err == nil || !errors.Is(err, store.ErrNotFound) {
+		return index, err
+	}
+
+	versions, err := versionNumbers(docs)
+	if err != nil || len(versions) == 0 {
+		return index, err
+	}
+	for _, version := range versions {
+		s, err := loadVersion(congregationID, period, version)
+		if err != nil {
+			return periodIndex{}, err
+		}
+		index.put(s.summary())
+	}
+	return index, docs.Save(indexKey, index)
+}
+
+// saveVersion grava a versão e atualiza o índice do período; quem chama segura
+// versionsMu.
+func saveVersion(congregationID string, s storedSchedule) error {
+	period := s.Manifest.Period
+	docs, err := versionsFor(congregationID, period)
+	if err != nil {
+		return err
+	}
+	index, err := loadIndex(congregationID, period)
+	if err != nil {
+		return err
+	}
+	if err := docs.Save(strconv.Itoa(s.Version), s); err != nil {
+		return err
+	}
+	index.put(s.summary())
+	return docs.Save(indexKey, index)
+}
+
+func loadVersion(congregationID, period string, version int) (storedSchedule, error) {
+	docs, err := versionsFor(congregationID, period)
+	if err != nil {
+		return storedSchedule{}, err
+	}
+	var s storedSchedule
+	err = docs.Load(strconv.Itoa(version), &s)
+	if errors.Is(err, store.ErrNotFound) {
+		return storedSchedule{}, ErrScheduleNotFound
+	}
+	return s, err
+}
+
+// loadSchedule devolve a versão mais recente do período.
+func loadSchedule(congregationID, period string) (storedSchedule, error) {
+	index, err := loadIndex(congregationID, period)
+	if err != nil {
+		return storedSchedule{}, err
+	}
+	if index.Latest == 0 {
+		return storedSchedule{}, ErrScheduleNotFound
+	}
+	return loadVersion(congregationID, period, index.Latest)
+}
+
+// ListSchedulePeriods devolve os períodos com programações guardadas, em ordem
+// alfabética.
+func ListSchedulePeriods(congregationID string) ([]SchedulePeriod, error) {
+	root, err := schedulesRoot(congregationID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read schedules dir: %w", err)
+	}
+
+	result := make([]SchedulePeriod, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || checkPeriod(entry.Name()) != nil {
+			continue
+		}
+		index, err := loadIndex(congregationID, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if index.Latest == 0 {
+			continue
+		}
+		result = append(result, SchedulePeriod{
+			Period:    entry.Name(),
+			Versions:  len(index.Versions),
+			Latest:    index.Latest,
+			Published: index.Published,
+		})
+	}
+	return result, nil
+}
+
+// ListScheduleVersions devolve as versões do período, da mais antiga para a
+// mais recente.
+func ListScheduleVersions(congregationID, period string) ([]ScheduleVersion, error) {
+	index, err := loadIndex(congregationID, period)
+	if err != nil {
+		return nil, err
+	}
+	if len(index.Versions) == 0 {
+		return nil, ErrScheduleNotFound
+	}
+	return index.Versions, nil
+}
+
+func GetScheduleVersion(congregationID, period string, version int) (ScheduleVersionDetail, error) {
+	s, err := loadVersion(congregationID, period, version)
+	if err != nil {
+		return ScheduleVersionDetail{}, err
+	}
+	return ScheduleVersionDetail{
+		ScheduleVersion: s.summary(),
+		Manifest:        s.Manifest,
+		Meetings:        s.Meetings,
+		Slots:           s.Slots,
+		Conflicts:       s.Conflicts,
+	}, nil
+}
+
+// DownloadScheduleVersion devolve o zip entregue quando a versão foi gerada.
+func DownloadScheduleVersion(congregationID, period string, version int) ([]byte, error) {
+	s, err := loadVersion(congregationID, period, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.Outputs, nil
+}
+
+// PublishScheduleVersion marca a versão como a publicada do período; só uma
+// versão fica publicada por vez.
+func PublishScheduleVersion(congregationID, period string, version int) (ScheduleVersion, error) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+
+	index, err := loadIndex(congregationID, period)
+	if err != nil {
+		return ScheduleVersion{}, err
+	}
+	if !index.has(version) {
+		return ScheduleVersion{}, ErrScheduleNotFound
+	}
+
+	published, err := loadVersion(congregationID, period, version)
+	if err != nil {
+		return ScheduleVersion{}, err
+	}
+	if published.Published {
+		return published.summary(), nil
+	}
+
+	if index.Published != 0 {
+		previous, err := loadVersion(congregationID, period, index.Published)
+		if err != nil {
+			return ScheduleVersion{}, err
+		}
+		previous.Published = false
+		previous.PublishedAt = nil
+		if err := saveVersion(congregationID, previous); err != nil {
+			return ScheduleVersion{}, fmt.Errorf("failed to store schedule: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	published.Published = true
+	published.PublishedAt = &now
+	if err := saveVersion(congregationID, published); err != nil {
+		return ScheduleVersion{}, fmt.Errorf("failed to store schedule: %w", err)
+	}
+	return published.summary(), nil
+}
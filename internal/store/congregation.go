@@ -0,0 +1,146 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+// Documentos que a geração da programação pode incluir no zip.
+const (
+	OutputSchedule   = "schedule"
+	OutputDesignates = "designates"
+	OutputSlips      = "slips"
+)
+
+var Outputs = []string{OutputSchedule, OutputDesignates, OutputSlips}
+
+// Congregation é o perfil de uma congregação. Cada uma tem seus próprios
+// publicadores e histórico, de modo que várias podem usar a mesma instalação.
+type Congregation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Classrooms é o número de salas com designações de estudante, de 1 a 3.
+	Classrooms int `json:"classrooms"`
+	// MeetingDay é o dia da reunião em inglês ("tuesday") e MeetingTime o
+	// horário de início no formato HH:MM.
+	MeetingDay  string `json:"meeting_day"`
+	MeetingTime string `json:"meeting_time"`
+	Locale      string `json:"locale"`
+	// Outputs lista os documentos incluídos no zip; vazio inclui todos.
+	Outputs []string `json:"outputs"`
+	// HallNames substitui os nomes padrão das salas, a principal primeiro.
+	HallNames []string `json:"hall_names,omitempty"`
+}
+
+// Includes indica se o documento faz parte da saída da congregação.
+func (c Congregation) Includes(output string) bool {
+	if len(c.Outputs) == 0 {
+		return true
+	}
+	for _, o := range c.Outputs {
+		if o == output {
+			return true
+		}
+	}
+	return false
+}
+
+type CongregationRepository interface {
+	Congregations() ([]Congregation, error)
+	Congregation(id string) (Congregation, error)
+	// SaveCongregation cria o perfil ou substitui o que tem o mesmo ID.
+	SaveCongregation(c Congregation) error
+	DeleteCongregation(id string) error
+}
+
+// JSONCongregations guarda os perfis num único arquivo JSON.
+type JSONCongregations struct {
+	path   string
+	mu     sync.Mutex
+	loaded bool
+	data   []Congregation
+}
+
+func NewJSONCongregations(path string) *JSONCongregations {
+	return &JSONCongregations{path: path}
+}
+
+func (r *JSONCongregations) Congregations() ([]Congregation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return append([]Congregation(nil), r.data...), nil
+}
+
+func (r *JSONCongregations) Congregation(id string) (Congregation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return Congregation{}, err
+	}
+	for _, c := range r.data {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return Congregation{}, ErrNotFound
+}
+
+func (r *JSONCongregations) SaveCongregation(c Congregation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	replaced := false
+	for i := range r.data {
+		if r.data[i].ID == c.ID {
+			r.data[i] = c
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		r.data = append(r.data, c)
+		sort.SliceStable(r.data, func(i, j int) bool {
+			return r.data[i].ID < r.data[j].ID
+		})
+	}
+	return r.save()
+}
+
+func (r *JSONCongregations) DeleteCongregation(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	for i, c := range r.data {
+		if c.ID == id {
+			r.data = append(r.data[:i], r.data[i+1:]...)
+			return r.save()
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *JSONCongregations) load() error {
+	if r.loaded {
+		return nil
+	}
+	if err := readJSONFile(r.path, &r.data); err != nil {
+		return err
+	}
+	r.loaded = true
+	return nil
+}
+
+func (r *JSONCongregations) save() error {
+	return writeJSONFile(r.path, r.data)
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// JSONDocuments guarda um documento JSON por chave num diretório, como cada
+// versão gerada da programação de um período.
+type JSONDocuments struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewJSONDocuments(dir string) *JSONDocuments {
+	return &JSONDocuments{dir: dir}
+}
+
+// Load decodifica o documento key em v; devolve ErrNotFound se ele não existir.
+func (d *JSONDocuments) Load(key string, v any) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return readJSONFile(path, v)
+}
+
+func (d *JSONDocuments) Save(key string, v any) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return writeJSONFile(path, v)
+}
+
+// Keys devolve as chaves dos documentos guardados, em ordem alfabética. Um
+// diretório que ainda não existe não tem documentos.
+func (d *JSONDocuments) Keys() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries, err := os.ReadDir(d.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documents dir: %w", err)
+	}
+
+	var keys []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			keys = append(keys, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	return keys, nil
+}
+
+func (d *JSONDocuments) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		return "", fmt.Errorf("invalid document key %q", key)
+	}
+	return filepath.Join(d.dir, key+".json"), nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import "errors"
+
+// ErrStagedDone é devolvido por Commit depois de Commit ou Rollback.
+var ErrStagedDone = errors.New("staged changes already committed or discarded")
+
+// keyedWriter grava registros que já trazem o ID, para que os criados numa
+// StagedRepository cheguem ao store com o ID devolvido a quem os criou.
+type keyedWriter interface {
+	putUnavailability(u Unavailability) error
+	putAuditEntry(e AuditEntry) error
+}
+
+// stageLocker é implementado pelos repositórios que aceitam uma
+// StagedRepository por vez; a segunda espera a primeira terminar.
+type stageLocker interface {
+	lockStage()
+	unlockStage()
+}
+
+// StagedRepository acumula em memória as alterações feitas sobre outro
+// Repository e só as grava nele em Commit. As leituras já enxergam as
+// alterações pendentes, então uma geração pode importar a planilha e montar a
+// escala sem mexer no store se terminar em erro. Enquanto a cópia existe,
+// outra cópia do mesmo repositório espera: duas gerações não partem do mesmo
+// estado para gravar uma por cima da outra. Quem cria a cópia chama Commit ou
+// Rollback.
+type StagedRepository struct {
+	base    Repository
+	view    *JSONRepository
+	pending []func(Repository) error
+	locker  stageLocker
+	done    bool
+}
+
+// NewStagedRepository copia o conteúdo atual de base para a memória.
+func NewStagedRepository(base Repository) (*StagedRepository, error) {
+	s := &StagedRepository{base: base}
+	if locker, ok := base.(stageLocker); ok {
+		locker.lockStage()
+		s.locker = locker
+	}
+
+	var data fileData
+	var err error
+	if data.Publishers, err = base.Publishers(); err != nil {
+		s.release()
+		return nil, err
+	}
+	if data.Assignments, err = base.Assignments(); err != nil {
+		s.release()
+		return nil, err
+	}
+	if data.Unavailabilities, err = base.Unavailabilities(); err != nil {
+		s.release()
+		return nil, err
+	}
+	if data.Pairings, err = base.Pairings(); err != nil {
+		s.release()
+		return nil, err
+	}
+	if data.Audit, err = base.AuditEntries(); err != nil {
+		s.release()
+		return nil, err
+	}
+	s.view = &JSONRepository{loaded: true, data: data}
+	return s, nil
+}
+
+// Commit grava em base, na ordem em que foram feitas, as alterações pendentes
+// e libera o repositório para outra cópia.
+func (s *StagedRepository) Commit() error {
+	if s.done {
+		return ErrStagedDone
+	}
+	defer s.release()
+
+	for len(s.pending) > 0 {
+		if err := s.pending[0](s.base); err != nil {
+			return err
+		}
+		s.pending = s.pending[1:]
+	}
+	return nil
+}
+
+// Rollback descarta as alterações pendentes. Depois de Commit não faz nada,
+// então pode ficar num defer logo depois de NewStagedRepository.
+func (s *StagedRepository) Rollback() {
+	s.pending = nil
+	s.release()
+}
+
+func (s *StagedRepository) release() {
+	s.done = true
+	if s.locker != nil {
+		s.locker.unlockStage()
+		s.locker = nil
+	}
+}
+
+func (s *StagedRepository) stage(apply func(Repository) error) error {
+	if err := apply(s.view); err != nil {
+		return err
+	}
+	s.pending = append(s.pending, apply)
+	return nil
+}
+
+func (s *StagedRepository) Publishers() ([]Publisher, error) {
+	return s.view.Publishers()
+}
+
+func (s *StagedRepository) SavePublishers(publishers []Publisher) error {
+	return s.stage(func(r Repository) error { return r.SavePublishers(publishers) })
+}
+
+func (s *StagedRepository) Assignments() ([]Assignment, error) {
+	return s.view.Assignments()
+}
+
+func (s *StagedRepository) AddAssignments(assignments []Assignment) error {
+	return s.stage(func(r Repository) error { return r.AddAssignments(assignments) })
+}
+
+func (s *StagedRepository) ReplacePeriodAssignments(period string, assignments []Assignment) error {
+	return s.stage(func(r Repository) error { return r.ReplacePeriodAssignments(period, assignments) })
+}
+
+func (s *StagedRepository) Unavailabilities() ([]Unavailability, error) {
+	return s.view.Unavailabilities()
+}
+
+func (s *StagedRepository) AddUnavailability(u Unavailability) (Unavailability, error) {
+	added, err := s.view.AddUnavailability(u)
+	if err != nil {
+		return Unavailability{}, err
+	}
+	s.pending = append(s.pending, func(r Repository) error { return putUnavailability(r, added) })
+	return added, nil
+}
+
+func (s *StagedRepository) putUnavailability(u Unavailability) error {
+	return s.stage(func(r Repository) error { return putUnavailability(r, u) })
+}
+
+func (s *StagedRepository) DeleteUnavailability(id string) error {
+	return s.stage(func(r Repository) error { return r.DeleteUnavailability(id) })
+}
+
+func (s *StagedRepository) ReplaceUnavailabilities(source string, entries []Unavailability) error {
+	return s.stage(func(r Repository) error { return r.ReplaceUnavailabilities(source, entries) })
+}
+
+func (s *StagedRepository) Pairings() ([]Pairing, error) {
+	return s.view.Pairings()
+}
+
+func (s *StagedRepository) ReplacePairings(pairings []Pairing) error {
+	return s.stage(func(r Repository) error { return r.ReplacePairings(pairings) })
+}
+
+func (s *StagedRepository) AuditEntries() ([]AuditEntry, error) {
+	return s.view.AuditEntries()
+}
+
+func (s *StagedRepository) AddAuditEntry(e AuditEntry) (AuditEntry, error) {
+	added, err := s.view.AddAuditEntry(e)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	s.pending = append(s.pending, func(r Repository) error { return putAuditEntry(r, added) })
+	return added, nil
+}
+
+func (s *StagedRepository) putAuditEntry(e AuditEntry) error {
+	return s.stage(func(r Repository) error { return putAuditEntry(r, e) })
+}
+
+// putUnavailability grava u em r mantendo o ID; repositórios sem keyedWriter
+// recebem um ID novo.
+func putUnavailability(r Repository, u Unavailability) error {
+	if w, ok := r.(keyedWriter); ok {
+		return w.putUnavailability(u)
+	}
+	_, err := r.AddUnavailability(u)
+	return err
+}
+
+// putAuditEntry grava e em r mantendo o ID, como putUnavailability.
+func putAuditEntry(r Repository, e AuditEntry) error {
+	if w, ok := r.(keyedWriter); ok {
+		return w.putAuditEntry(e)
+	}
+	_, err := r.AddAuditEntry(e)
+	return err
+}
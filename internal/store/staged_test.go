@@ -0,0 +1,188 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestRepository(t *testing.T) (*JSONRepository, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "store.json")
+	repo := NewJSONRepository(path)
+	if err := repo.SavePublishers([]Publisher{{Name: "Ana"}, {Name: "João"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddAssignments([]Assignment{{Name: "Ana", Period: "2025-02", Part: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	return repo, path
+}
+
+func TestStagedRepositoryReadsPendingChanges(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	staged, err := NewStagedRepository(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer staged.Rollback()
+
+	if err := staged.SavePublishers([]Publisher{{Name: "Ana"}, {Name: "João"}, {Name: "Pedro"}}); err != nil {
+		t.Fatal(err)
+	}
+	publishers, err := staged.Publishers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(publishers) != 3 {
+		t.Errorf("staged view has %d publishers, want 3", len(publishers))
+	}
+	base, err := repo.Publishers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base) != 2 {
+		t.Errorf("base has %d publishers before Commit, want 2", len(base))
+	}
+}
+
+func TestStagedRepositoryCommit(t *testing.T) {
+	repo, path := newTestRepository(t)
+	staged, err := NewStagedRepository(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer staged.Rollback()
+
+	if err := staged.ReplacePeriodAssignments("2025-03", []Assignment{{Name: "João", Period: "2025-03", Part: "3"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := staged.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := staged.Commit(); err != ErrStagedDone {
+		t.Errorf("second Commit: got %v, want ErrStagedDone", err)
+	}
+
+	reloaded, err := NewJSONRepository(path).Assignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded) != 2 {
+		t.Fatalf("store has %d assignments after Commit, want 2", len(reloaded))
+	}
+}
+
+func TestStagedRepositoryRollback(t *testing.T) {
+	repo, path := newTestRepository(t)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staged, err := NewStagedRepository(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := staged.SavePublishers([]Publisher{{Name: "Pedro"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := staged.ReplacePeriodAssignments("2025-02", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := staged.AddAuditEntry(AuditEntry{Period: "2025-02", Action: "swap"}); err != nil {
+		t.Fatal(err)
+	}
+	staged.Rollback()
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("store changed after Rollback:\n%s", after)
+	}
+	if err := staged.Commit(); err != ErrStagedDone {
+		t.Errorf("Commit after Rollback: got %v, want ErrStagedDone", err)
+	}
+}
+
+func TestStagedRepositoryKeepsIDs(t *testing.T) {
+	repo, path := newTestRepository(t)
+	staged, err := NewStagedRepository(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer staged.Rollback()
+
+	u, err := staged.AddUnavailability(Unavailability{Name: "Ana", From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), Source: SourceAPI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := staged.AddAuditEntry(AuditEntry{Period: "2025-03", Action: "substitute"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID == "" || e.ID == "" {
+		t.Fatalf("staged records without ids: %q %q", u.ID, e.ID)
+	}
+	if err := staged.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewJSONRepository(path)
+	entries, err := reloaded.AuditEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != e.ID {
+		t.Errorf("audit entries %+v, want one with id %q", entries, e.ID)
+	}
+	if err := reloaded.DeleteUnavailability(u.ID); err != nil {
+		t.Errorf("DeleteUnavailability(%q) after Commit: %v", u.ID, err)
+	}
+}
+
+func TestStagedRepositoryWaitsForPreviousStage(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	first, err := NewStagedRepository(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := first.SavePublishers([]Publisher{{Name: "Pedro"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(chan []Publisher)
+	go func() {
+		second, err := NewStagedRepository(repo)
+		if err != nil {
+			t.Error(err)
+			close(seen)
+			return
+		}
+		defer second.Rollback()
+		publishers, _ := second.Publishers()
+		seen <- publishers
+	}()
+
+	select {
+	case <-seen:
+		t.Fatal("second stage started before the first one finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case publishers := <-seen:
+		if len(publishers) != 3 || publishers[2].Name != "Pedro" {
+			t.Errorf("second stage saw %+v, want the committed publishers", publishers)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second stage still waiting after Commit")
+	}
+}
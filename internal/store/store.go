@@ -0,0 +1,459 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	RoleHolder    = "holder"
+	RoleAssistant = "assistant"
+
+	HallMain      = "A"
+	HallAuxiliary = "B"
+	HallThird     = "C"
+)
+
+// Halls são as salas na ordem em que são abertas: uma congregação com duas
+// salas usa as duas primeiras.
+var Halls = []string{HallMain, HallAuxiliary, HallThird}
+
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+
+	AppointmentElder              = "elder"
+	AppointmentMinisterialServant = "ministerial_servant"
+)
+
+type Publisher struct {
+	Name      string   `json:"name"`
+	Functions []string `json:"functions"`
+	// Inactive marca quem saiu da planilha de designados: o histórico e os
+	// atributos ficam guardados, mas a pessoa não entra mais nas filas.
+	Inactive bool `json:"inactive,omitempty"`
+	Attributes
+}
+
+// Attributes são os dados do publicador que decidem quais partes ele pode
+// receber. Registered indica que vieram do cadastro; sem ele só o gênero, se
+// conhecido, é levado em conta.
+type Attributes struct {
+	Gender      string `json:"gender,omitempty"`
+	Baptized    bool   `json:"baptized,omitempty"`
+	Appointment string `json:"appointment,omitempty"`
+	StudentOnly bool   `json:"student_only,omitempty"`
+	// Household identifica a família: irmão e irmã só fazem par se forem da
+	// mesma família.
+	Household  string `json:"household,omitempty"`
+	Registered bool   `json:"registered,omitempty"`
+}
+
+const (
+	PairingPreferred = "preferred"
+	PairingForbidden = "forbidden"
+)
+
+// Pairing é uma preferência de par entre dois publicadores, nas duas ordens:
+// "preferred" favorece o par nas demonstrações e "forbidden" o impede.
+type Pairing struct {
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Kind   string `json:"kind"`
+}
+
+// Assignment é uma designação já feita. Period vazio indica histórico importado
+// da planilha de designados, sem parte nem sala conhecidas.
+type Assignment struct {
+	Period   string    `json:"period"`
+	Week     string    `json:"week"`
+	Date     time.Time `json:"date"`
+	Function string    `json:"function"`
+	Part     string    `json:"part"`
+	Role     string    `json:"role"`
+	Hall     string    `json:"hall"`
+	Name     string    `json:"name"`
+}
+
+// Unavailability é um intervalo de datas, inclusivo, em que o publicador não
+// pode receber designações. Source separa o que veio da planilha ("sheet") do
+// que foi cadastrado pela API ("api").
+type Unavailability struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Reason string    `json:"reason,omitempty"`
+	Source string    `json:"source"`
+}
+
+const (
+	SourceSheet = "sheet"
+	SourceAPI   = "api"
+)
+
+const (
+	AuditSwap       = "swap"
+	AuditSubstitute = "substitute"
+)
+
+// AuditEntry registra uma alteração manual numa programação já gerada: quem
+// fez, quando e que vagas mudaram.
+type AuditEntry struct {
+	ID     string    `json:"id"`
+	At     time.Time `json:"at"`
+	Period string    `json:"period"`
+	// Version é a versão da programação criada pela alteração.
+	Version int           `json:"version,omitempty"`
+	Action  string        `json:"action"`
+	By      string        `json:"by"`
+	Reason  string        `json:"reason,omitempty"`
+	Changes []AuditChange `json:"changes"`
+}
+
+// AuditChange é uma vaga alterada: Week é a posição da semana no período, a
+// partir de 1, e Slot é holder ou assistant.
+type AuditChange struct {
+	Week int    `json:"week"`
+	Part string `json:"part"`
+	Slot string `json:"slot"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Repository interface {
+	Publishers() ([]Publisher, error)
+	SavePublishers(publishers []Publisher) error
+	Assignments() ([]Assignment, error)
+	AddAssignments(assignments []Assignment) error
+	// ReplacePeriodAssignments troca todo o histórico de um período, para que
+	// gerar a mesma programação de novo não duplique designações.
+	ReplacePeriodAssignments(period string, assignments []Assignment) error
+	Unavailabilities() ([]Unavailability, error)
+	AddUnavailability(u Unavailability) (Unavailability, error)
+	DeleteUnavailability(id string) error
+	// ReplaceUnavailabilities troca todos os intervalos de uma origem, usado a
+	// cada nova importação da planilha.
+	ReplaceUnavailabilities(source string, entries []Unavailability) error
+	Pairings() ([]Pairing, error)
+	// ReplacePairings troca todas as preferências de par.
+	ReplacePairings(pairings []Pairing) error
+	AuditEntries() ([]AuditEntry, error)
+	AddAuditEntry(e AuditEntry) (AuditEntry, error)
+}
+
+var ErrNotFound = errors.New("not found")
+
+type fileData struct {
+	Publishers       []Publisher      `json:"publishers"`
+	Assignments      []Assignment     `json:"assignments"`
+	Unavailabilities []Unavailability `json:"unavailabilities"`
+	Pairings         []Pairing        `json:"pairings,omitempty"`
+	Audit            []AuditEntry     `json:"audit,omitempty"`
+}
+
+// JSONRepository guarda tudo num único arquivo JSON, regravado a cada alteração.
+type JSONRepository struct {
+	path   string
+	mu     sync.Mutex
+	loaded bool
+	data   fileData
+	// stageMu deixa uma StagedRepository por vez sobre o repositório.
+	stageMu sync.Mutex
+}
+
+func NewJSONRepository(path string) *JSONRepository {
+	return &JSONRepository{path: path}
+}
+
+func (r *JSONRepository) Publishers() ([]Publisher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return append([]Publisher(nil), r.data.Publishers...), nil
+}
+
+func (r *JSONRepository) SavePublishers(publishers []Publisher) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(r.data.Publishers))
+	for i, p := range r.data.Publishers {
+		index[p.Name] = i
+	}
+	for _, p := range publishers {
+		if i, ok := index[p.Name]; ok {
+			r.data.Publishers[i] = p
+			continue
+		}
+		index[p.Name] = len(r.data.Publishers)
+		r.data.Publishers = append(r.data.Publishers, p)
+	}
+	sort.SliceStable(r.data.Publishers, func(i, j int) bool {
+		return r.data.Publishers[i].Name < r.data.Publishers[j].Name
+	})
+
+	return r.save()
+}
+
+func (r *JSONRepository) Assignments() ([]Assignment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return append([]Assignment(nil), r.data.Assignments...), nil
+}
+
+func (r *JSONRepository) AddAssignments(assignments []Assignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	r.data.Assignments = append(r.data.Assignments, assignments...)
+	return r.save()
+}
+
+func (r *JSONRepository) ReplacePeriodAssignments(period string, assignments []Assignment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+
+	kept := r.data.Assignments[:0]
+	for _, a := range r.data.Assignments {
+		if a.Period != period {
+			kept = append(kept, a)
+		}
+	}
+	r.data.Assignments = append(kept, assignments...)
+	return r.save()
+}
+
+func (r *JSONRepository) Unavailabilities() ([]Unavailability, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return append([]Unavailability(nil), r.data.Unavailabilities...), nil
+}
+
+func (r *JSONRepository) AddUnavailability(u Unavailability) (Unavailability, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return Unavailability{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return Unavailability{}, err
+	}
+	u.ID = id
+	r.data.Unavailabilities = append(r.data.Unavailabilities, u)
+	return u, r.save()
+}
+
+// putUnavailability grava o intervalo com o ID que ele já traz.
+func (r *JSONRepository) putUnavailability(u Unavailability) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	r.data.Unavailabilities = append(r.data.Unavailabilities, u)
+	return r.save()
+}
+
+func (r *JSONRepository) DeleteUnavailability(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	for i, u := range r.data.Unavailabilities {
+		if u.ID == id {
+			r.data.Unavailabilities = append(r.data.Unavailabilities[:i], r.data.Unavailabilities[i+1:]...)
+			return r.save()
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *JSONRepository) ReplaceUnavailabilities(source string, entries []Unavailability) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+
+	kept := r.data.Unavailabilities[:0]
+	for _, u := range r.data.Unavailabilities {
+		if u.Source != source {
+			kept = append(kept, u)
+		}
+	}
+	for _, u := range entries {
+		id, err := newID()
+		if err != nil {
+			return err
+		}
+		u.ID = id
+		u.Source = source
+		kept = append(kept, u)
+	}
+	r.data.Unavailabilities = kept
+	return r.save()
+}
+
+func (r *JSONRepository) Pairings() ([]Pairing, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return append([]Pairing(nil), r.data.Pairings...), nil
+}
+
+func (r *JSONRepository) ReplacePairings(pairings []Pairing) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	r.data.Pairings = append([]Pairing(nil), pairings...)
+	return r.save()
+}
+
+func (r *JSONRepository) AuditEntries() ([]AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return append([]AuditEntry(nil), r.data.Audit...), nil
+}
+
+func (r *JSONRepository) AddAuditEntry(e AuditEntry) (AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return AuditEntry{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	e.ID = id
+	r.data.Audit = append(r.data.Audit, e)
+	return e, r.save()
+}
+
+// putAuditEntry grava o registro com o ID que ele já traz.
+func (r *JSONRepository) putAuditEntry(e AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return err
+	}
+	r.data.Audit = append(r.data.Audit, e)
+	return r.save()
+}
+
+func (r *JSONRepository) lockStage()   { r.stageMu.Lock() }
+func (r *JSONRepository) unlockStage() { r.stageMu.Unlock() }
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (r *JSONRepository) load() error {
+	if r.loaded {
+		return nil
+	}
+	if err := readJSONFile(r.path, &r.data); err != nil {
+		return err
+	}
+	r.loaded = true
+	return nil
+}
+
+// save regrava o arquivo; sem caminho, como na cópia de StagedRepository, o
+// repositório fica só em memória.
+func (r *JSONRepository) save() error {
+	if r.path == "" {
+		return nil
+	}
+	return writeJSONFile(r.path, r.data)
+}
+
+// readJSONFile decodifica o arquivo em v; arquivo inexistente deixa v vazio.
+func readJSONFile(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read store %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to decode store %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSONFile grava num arquivo temporário e renomeia, para não corromper o
+// store se o processo cair no meio da escrita.
+func writeJSONFile(path string, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create store dir: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
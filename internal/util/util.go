@@ -8,6 +8,7 @@ import (
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/transform"
 	"io"
+	"midweek-project/internal/rtf"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,9 +16,8 @@ import (
 )
 
 const (
-	rtfExtension          = ".rtf"
-	libreOfficeEnvVar     = "LIBREOFFICE_PATH"
-	defaultLibreOfficeCmd = "libreoffice"
+	rtfExtension      = ".rtf"
+	libreOfficeEnvVar = "LIBREOFFICE_PATH"
 )
 
 func UnzipRTFFiles(pathUnzipped, tmp string) ([]string, error) {
@@ -57,11 +57,11 @@ func NormalizeLine(line string) string {
 	return strings.ReplaceAll(line, "\u00A0", " ")
 }
 
-func getLibreOfficeCommand() string {
-	if cmd := os.Getenv(libreOfficeEnvVar); cmd != "" {
-		return cmd
-	}
-	return defaultLibreOfficeCmd
+// getLibreOfficeCommand retorna o binário do LibreOffice usado como fallback
+// da conversão nativa; só é habilitado quando LIBREOFFICE_PATH está definido.
+func getLibreOfficeCommand() (string, bool) {
+	cmd := os.Getenv(libreOfficeEnvVar)
+	return cmd, cmd != ""
 }
 
 func readAndDecodeFile(filePath string) (string, error) {
@@ -98,8 +98,27 @@ func detectEncoding(data []byte) (string, error) {
 }
 
 func ConvertSingleRTFToTXT(inputPath, outputPath string) error {
-	libreOfficeCmd := getLibreOfficeCommand()
+	rawContent, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", inputPath, err)
+	}
+
+	text, err := rtf.ToText(rawContent)
+	if err != nil {
+		libreOfficeCmd, ok := getLibreOfficeCommand()
+		if !ok {
+			return fmt.Errorf("failed to convert %s: %w", inputPath, err)
+		}
+		return convertWithLibreOffice(libreOfficeCmd, inputPath, outputPath)
+	}
+
+	if err := os.WriteFile(outputPath, []byte(text), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return nil
+}
 
+func convertWithLibreOffice(libreOfficeCmd, inputPath, outputPath string) error {
 	cmd := exec.Command(libreOfficeCmd, "--headless", "--convert-to", "txt:Text", "--outdir", filepath.Dir(outputPath), inputPath)
 
 	var stderr bytes.Buffer
@@ -0,0 +1,255 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"midweek-project/internal/locale"
+	"strings"
+)
+
+const (
+	SlipsPerPageOne  = 1
+	SlipsPerPageFour = 4
+
+	// A4 em twips (1/20 de ponto), com margens de meia polegada.
+	pageWidth    = 11906
+	pageHeight   = 16838
+	pageMargin   = 720
+	slipWidth    = (pageWidth - 2*pageMargin) / 2
+	slipHeight   = 7500
+	checkedBox   = "☒"
+	uncheckedBox = "☐"
+
+	// maxMaterialRunes limita a matéria para caber na altura fixa da papeleta.
+	maxMaterialRunes = 220
+)
+
+type slip struct {
+	Student   string
+	Assistant string
+	Date      string
+	Part      string
+	Location  string
+
+	// StudyPoint é a lição de conselho e Material o texto da parte na apostila.
+	StudyPoint string
+	Material   string
+}
+
+const contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const documentRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="%s"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="80" w:line="240" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="table" w:default="1" w:styleId="TableNormal"><w:name w:val="Normal Table"/><w:tblPr><w:tblInd w:w="0" w:type="dxa"/><w:tblCellMar><w:top w:w="0" w:type="dxa"/><w:left w:w="108" w:type="dxa"/><w:bottom w:w="0" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>`
+
+const coreXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>%s</dc:title>
+</cp:coreProperties>`
+
+// buildDocx monta um pacote WordprocessingML com as designações no formato S-89,
+// uma por página ou quatro por folha A4, com os textos no idioma de loc e as
+// salas da congregação.
+func buildDocx(slips []slip, slipsPerPage int, title string, loc *locale.Locale, halls []string) ([]byte, error) {
+	var body strings.Builder
+	switch slipsPerPage {
+	case SlipsPerPageOne:
+		writeSlipPages(&body, slips, loc.Labels, halls)
+	case SlipsPerPageFour:
+		writeSlipGrid(&body, slips, loc.Labels, halls)
+	default:
+		return nil, fmt.Errorf("unsupported slips per page: %d", slipsPerPage)
+	}
+
+	var document strings.Builder
+	document.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	document.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	document.WriteString(body.String())
+	document.WriteString(fmt.Sprintf(
+		`<w:sectPr><w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="0" w:footer="0" w:gutter="0"/></w:sectPr>`,
+		pageWidth, pageHeight, pageMargin, pageMargin, pageMargin, pageMargin,
+	))
+	document.WriteString(`</w:body></w:document>`)
+
+	files := []struct {
+		Name    string
+		Content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"docProps/core.xml", fmt.Sprintf(coreXML, escapeXML(title))},
+		{"word/_rels/document.xml.rels", documentRelsXML},
+		{"word/styles.xml", fmt.Sprintf(stylesXML, loc.Tag)},
+		{"word/document.xml", document.String()},
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zipWriter.Create(file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", file.Name, err)
+		}
+		if _, err := w.Write([]byte(file.Content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeSlipPages(body *strings.Builder, slips []slip, labels locale.Labels, halls []string) {
+	if len(slips) == 0 {
+		body.WriteString(`<w:p/>`)
+		return
+	}
+	for i, s := range slips {
+		writeSlip(body, s, labels, halls)
+		if i < len(slips)-1 {
+			body.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>`)
+		}
+	}
+}
+
+// writeSlipGrid distribui as designações numa tabela 2x2 por página, com
+// bordas tracejadas servindo de linha de corte.
+func writeSlipGrid(body *strings.Builder, slips []slip, labels locale.Labels, halls []string) {
+	if len(slips) == 0 {
+		body.WriteString(`<w:p/>`)
+		return
+	}
+
+	body.WriteString(`<w:tbl><w:tblPr>`)
+	body.WriteString(fmt.Sprintf(`<w:tblW w:w="%d" w:type="dxa"/><w:tblLayout w:type="fixed"/>`, slipWidth*2))
+	body.WriteString(`<w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		body.WriteString(fmt.Sprintf(`<w:%s w:val="dashed" w:sz="4" w:space="0" w:color="999999"/>`, side))
+	}
+	body.WriteString(`</w:tblBorders><w:tblCellMar><w:top w:w="200" w:type="dxa"/><w:left w:w="240" w:type="dxa"/><w:bottom w:w="200" w:type="dxa"/><w:right w:w="240" w:type="dxa"/></w:tblCellMar>`)
+	body.WriteString(`</w:tblPr>`)
+	body.WriteString(fmt.Sprintf(`<w:tblGrid><w:gridCol w:w="%d"/><w:gridCol w:w="%d"/></w:tblGrid>`, slipWidth, slipWidth))
+
+	for i := 0; i < len(slips); i += 2 {
+		body.WriteString(fmt.Sprintf(`<w:tr><w:trPr><w:cantSplit/><w:trHeight w:val="%d" w:hRule="exact"/></w:trPr>`, slipHeight))
+		for j := i; j < i+2; j++ {
+			body.WriteString(fmt.Sprintf(`<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr>`, slipWidth))
+			if j < len(slips) {
+				writeSlip(body, slips[j], labels, halls)
+			} else {
+				body.WriteString(`<w:p/>`)
+			}
+			body.WriteString(`</w:tc>`)
+		}
+		body.WriteString(`</w:tr>`)
+	}
+
+	// Word exige um parágrafo depois da tabela; mínimo para não gerar página em branco.
+	body.WriteString(`</w:tbl><w:p><w:pPr><w:spacing w:after="0" w:line="20" w:lineRule="exact"/></w:pPr></w:p>`)
+}
+
+func writeSlip(body *strings.Builder, s slip, l locale.Labels, halls []string) {
+	writeParagraph(body, l.SlipTitle, true, 24, "center")
+	writeParagraph(body, l.SlipSubtitle, true, 24, "center")
+	writeParagraph(body, "", false, 0, "")
+	writeField(body, l.SlipName, s.Student)
+	writeField(body, l.SlipAssistant, s.Assistant)
+	writeField(body, l.SlipDate, s.Date)
+	writeField(body, l.SlipPart, s.Part)
+	if s.StudyPoint != "" {
+		writeField(body, l.SlipStudyPoint, s.StudyPoint)
+	}
+	if s.Material != "" {
+		writeParagraph(body, l.SlipMaterial+": "+truncateRunes(strings.ReplaceAll(s.Material, "\n", " "), maxMaterialRunes), false, 16, "")
+	}
+	writeParagraph(body, "", false, 0, "")
+	writeParagraph(body, l.SlipLocation+":", true, 0, "")
+	for _, hall := range halls {
+		box := uncheckedBox
+		if s.Location == hall {
+			box = checkedBox
+		}
+		writeParagraph(body, box+" "+hall, false, 0, "")
+	}
+	writeParagraph(body, "", false, 0, "")
+	writeParagraph(body, l.SlipNote, false, 16, "")
+	writeParagraph(body, l.SlipFormCode, false, 14, "")
+}
+
+func writeField(body *strings.Builder, label, value string) {
+	body.WriteString(`<w:p>`)
+	body.WriteString(run(label+": ", true, 0))
+	body.WriteString(run(value, false, 0))
+	body.WriteString(`</w:p>`)
+}
+
+func writeParagraph(body *strings.Builder, text string, bold bool, size int, align string) {
+	body.WriteString(`<w:p>`)
+	if align != "" {
+		body.WriteString(fmt.Sprintf(`<w:pPr><w:jc w:val="%s"/></w:pPr>`, align))
+	}
+	if text != "" {
+		body.WriteString(run(text, bold, size))
+	}
+	body.WriteString(`</w:p>`)
+}
+
+func run(text string, bold bool, size int) string {
+	var props strings.Builder
+	if bold {
+		props.WriteString(`<w:b/>`)
+	}
+	if size > 0 {
+		props.WriteString(fmt.Sprintf(`<w:sz w:val="%d"/><w:szCs w:val="%d"/>`, size, size))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<w:r>`)
+	if props.Len() > 0 {
+		sb.WriteString(`<w:rPr>` + props.String() + `</w:rPr>`)
+	}
+	sb.WriteString(`<w:t xml:space="preserve">` + escapeXML(text) + `</w:t></w:r>`)
+	return sb.String()
+}
+
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+func escapeXML(text string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
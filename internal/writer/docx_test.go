@@ -0,0 +1,89 @@
+package writer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"midweek-project/internal/parser"
+	"strings"
+	"testing"
+)
+
+func testMeetings() []parser.MeetingData {
+	return []parser.MeetingData{{
+		MeetingDate: "3 a 9 de março",
+		Parts: []parser.Part{
+			{Number: 3, Title: "Leitura da Bíblia", Minutes: 4, Section: parser.SectionTreasures, Kind: parser.KindBibleReading},
+			{Number: 4, Title: "Iniciando conversas", Minutes: 3, Section: parser.SectionMinistry, Kind: parser.KindStartingConversation, StudyReference: "lmd lição 1 ponto 3"},
+		},
+		Designated: map[string]string{
+			"3.A": "Lucas Rocha",
+			"4.A": "Ana Souza/Bia Lima",
+			"4.B": "Clara Dias & Filhos",
+		},
+	}}
+}
+
+// readDocx abre o pacote e devolve o conteúdo de cada parte.
+func readDocx(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[file.Name] = string(content)
+	}
+	return parts
+}
+
+func TestGenerateDesignationsDoc(t *testing.T) {
+	for _, perPage := range []int{SlipsPerPageOne, SlipsPerPageFour} {
+		data, err := GenerateDesignationsDoc(testMeetings(), "2025-03", perPage, DefaultOptions())
+		if err != nil {
+			t.Fatalf("%d per page: %v", perPage, err)
+		}
+		parts := readDocx(t, data)
+
+		for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/_rels/document.xml.rels", "word/document.xml", "word/styles.xml", "docProps/core.xml"} {
+			content, ok := parts[name]
+			if !ok {
+				t.Errorf("%d per page: missing %s", perPage, name)
+				continue
+			}
+			decoder := xml.NewDecoder(strings.NewReader(content))
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Errorf("%d per page: %s is not well-formed: %v", perPage, name, err)
+					break
+				}
+			}
+		}
+
+		document := parts["word/document.xml"]
+		for _, name := range []string{"Lucas Rocha", "Ana Souza", "Bia Lima", "Clara Dias &amp; Filhos"} {
+			if !strings.Contains(document, name) {
+				t.Errorf("%d per page: document.xml has no %q", perPage, name)
+			}
+		}
+	}
+}
+
+func TestGenerateDesignationsDocSlipsPerPage(t *testing.T) {
+	if _, err := GenerateDesignationsDoc(testMeetings(), "2025-03", 2, DefaultOptions()); err == nil {
+		t.Error("2 slips per page succeeded, want an error")
+	}
+}
@@ -4,18 +4,90 @@ import (
 	"fmt"
 	"github.com/xuri/excelize/v2"
 	"io"
+	"midweek-project/internal/agenda"
+	"midweek-project/internal/assigner"
+	"midweek-project/internal/locale"
 	"midweek-project/internal/parser"
-	"sort"
+	"midweek-project/internal/store"
 	"strings"
+	"time"
 )
 
-const (
-	sectionTreasures  = "TESOUROS DA PALAVRA DE DEUS"
-	sectionMinistry   = "FAÇA SEU MELHOR NO MINISTÉRIO"
-	sectionChristians = "NOSSA VIDA CRISTÃ"
-)
+type Options struct {
+	// MeetingStart é o horário de início da reunião, contado da meia-noite.
+	MeetingStart time.Duration
+	// Locale define o idioma dos textos da programação e das papeletas.
+	Locale *locale.Locale
+	// Congregation é o nome impresso no topo de cada semana.
+	Congregation string
+	// HallNames substitui, pela posição, os nomes padrão das salas do idioma.
+	HallNames []string
+	// Classrooms é o número de salas com partes de estudante; zero vale o
+	// padrão do assigner.
+	Classrooms int
+}
+
+func DefaultOptions() Options {
+	return Options{MeetingStart: agenda.DefaultStart, Locale: locale.MustGet(locale.Default)}
+}
+
+func (o Options) locale() *locale.Locale {
+	if o.Locale == nil {
+		return locale.MustGet(locale.Default)
+	}
+	return o.Locale
+}
+
+// hallColumn é uma sala com partes de estudante e a coluna dela na programação.
+type hallColumn struct {
+	// code é a sala no sufixo das chaves de Designated, como "3.B".
+	code string
+	name string
+	col  string
+}
 
-func WriteToBuffer(meetings []parser.MeetingData, out io.Writer) error {
+// layout posiciona as colunas da programação. A sala principal fica sempre na
+// coluna C, junto dos demais designados, e a sala B na coluna B; a sala C,
+// quando houver, entra na coluna D e empurra horário e ponto de estudo.
+type layout struct {
+	halls  []hallColumn
+	agenda string
+	study  string
+}
+
+var hallColumns = map[string]string{
+	store.HallMain:      "C",
+	store.HallAuxiliary: "B",
+	store.HallThird:     "D",
+}
+
+func (o Options) layout(loc *locale.Locale) layout {
+	lay := layout{agenda: "D", study: "E"}
+	names := o.halls(loc)
+	for i, code := range assigner.Halls(o.Classrooms) {
+		lay.halls = append(lay.halls, hallColumn{code: code, name: names[i], col: hallColumns[code]})
+	}
+	if len(lay.halls) > 2 {
+		lay.agenda, lay.study = "E", "F"
+	}
+	return lay
+}
+
+// halls devolve os nomes das salas em uso, a principal primeiro.
+func (o Options) halls(loc *locale.Locale) []string {
+	defaults := []string{loc.Labels.HallMain, loc.Labels.HallAuxiliary, loc.Labels.HallThird}
+	names := defaults[:len(assigner.Halls(o.Classrooms))]
+	for i, name := range o.HallNames {
+		if i < len(names) && name != "" {
+			names[i] = name
+		}
+	}
+	return names
+}
+
+func WriteToBuffer(meetings []parser.MeetingData, out io.Writer, opts Options) error {
+	loc := opts.locale()
+	lay := opts.layout(loc)
 	f := excelize.NewFile()
 	styles := createStyles(f)
 
@@ -32,23 +104,20 @@ func WriteToBuffer(meetings []parser.MeetingData, out io.Writer) error {
 			_, _ = f.NewSheet(sheet)
 		}
 
-		prepareSheetLayout(f, sheet)
-		row := writeHeader(f, sheet, meeting, styles)
-
-		sections := []struct {
-			Name  string
-			Items map[string]string
-		}{
-			{sectionTreasures, meeting.TreasuresFromGodsWord},
-			{sectionMinistry, meeting.ApplyYourselfToTheFieldMinistry},
-			{sectionChristians, meeting.LivingAsChristians},
+		prepareSheetLayout(f, sheet, lay)
+		if !meeting.Type.HasMeeting() {
+			writeNoMeeting(f, sheet, meeting, opts, loc, styles)
+			continue
 		}
 
-		for _, sec := range sections {
-			row = writeSection(f, sheet, row, sec.Name, sec.Items, meeting, styles)
+		timing := agenda.Build(meeting, opts.MeetingStart)
+		row := writeHeader(f, sheet, meeting, timing, opts, lay, loc, styles)
+
+		for _, section := range parser.Sections {
+			row = writeSection(f, sheet, row, section, meeting, timing, lay, loc, styles)
 		}
 
-		writeFooter(f, sheet, row, meeting, styles)
+		writeFooter(f, sheet, row, meeting, timing, lay, loc, styles)
 	}
 
 	return f.Write(out)
@@ -76,6 +145,10 @@ func createStyles(f *excelize.File) map[string]int {
 	small, _ := f.NewStyle(&excelize.Style{
 		Font: &excelize.Font{Family: "Calibri", Size: 8},
 	})
+	alert, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "#FFFFFF", Bold: true, Family: "Calibri", Size: 8},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#c00000"}, Pattern: 1},
+	})
 
 	return map[string]int{
 		"gray":    gray,
@@ -84,81 +157,104 @@ func createStyles(f *excelize.File) map[string]int {
 		"content": content,
 		"bold":    bold,
 		"small":   small,
+		"alert":   alert,
 	}
 }
 
-func prepareSheetLayout(f *excelize.File, sheet string) {
+func prepareSheetLayout(f *excelize.File, sheet string, lay layout) {
 	_ = f.SetColWidth(sheet, "A", "A", 70)
 	_ = f.SetColWidth(sheet, "B", "B", 15)
 	_ = f.SetColWidth(sheet, "C", "C", 15)
+	for _, hall := range lay.halls {
+		_ = f.SetColWidth(sheet, hall.col, hall.col, 15)
+	}
+	_ = f.SetColWidth(sheet, lay.agenda, lay.agenda, 14)
+	_ = f.SetColWidth(sheet, lay.study, lay.study, 20)
 }
 
-func writeHeader(f *excelize.File, sheet string, m parser.MeetingData, s map[string]int) int {
+// counselors traz, por sala auxiliar, a chave do conselheiro em Designated.
+var counselors = map[string]string{
+	store.HallAuxiliary: assigner.FUNC_CONSELHEIRO,
+	store.HallThird:     assigner.FUNC_CONSELHEIRO_C,
+}
+
+func writeHeader(f *excelize.File, sheet string, m parser.MeetingData, timing agenda.Agenda, opts Options, lay layout, loc *locale.Locale, s map[string]int) int {
+	l := loc.Labels
 	row := 1
-	setStyledCell(f, sheet, row, "A", "CONGREGAÇÃO VILA CABRAL", s["bold"], true, true)
+	writeCongregation(f, sheet, row, opts, s)
 	row += 2
-	setStyledCell(f, sheet, row, "A", "Semana: "+m.MeetingDate, s["bold"], false, false)
-	setStyledCell(f, sheet, row, "C", "Presidente: "+getDesignated(m, "Presidente"), s["small"], false, false)
+	setStyledCell(f, sheet, row, "A", l.Week+": "+m.MeetingDate, s["bold"], false, false)
+	setStyledCell(f, sheet, row, "C", l.Chairman+": "+getDesignated(m, assigner.FUNC_PRESIDENTE), s["small"], false, false)
+	if timing.Overrun > 0 {
+		overrun := fmt.Sprintf(l.Overrun, int(timing.Overrun/time.Minute), agenda.FormatClock(timing.End))
+		setStyledCell(f, sheet, row, lay.agenda, overrun, s["alert"], false, false)
+	} else {
+		setStyledCell(f, sheet, row, lay.agenda, l.EndsAt+" "+agenda.FormatClock(timing.End), s["small"], false, false)
+	}
 	row++
-	setStyledCell(f, sheet, row, "C", "Conselheiro sala B: "+getDesignated(m, "Conselheiro Sala B"), s["small"], false, false)
+	// Os conselheiros das salas auxiliares ficam lado a lado, a partir da coluna C.
+	counselorLabels := map[string]string{
+		store.HallAuxiliary: l.AuxiliaryCounselor,
+		store.HallThird:     l.ThirdCounselor,
+	}
+	for i, hall := range lay.halls[1:] {
+		col, _ := excelize.ColumnNumberToName(3 + i)
+		setStyledCell(f, sheet, row, col, counselorLabels[hall.code]+": "+getDesignated(m, counselors[hall.code]), s["small"], false, false)
+	}
 	row++
-	setStyledCell(f, sheet, row, "A", "Cântico Inicial: "+m.InitSong, s["content"], false, false)
-	setStyledCell(f, sheet, row, "C", "Oração: "+getDesignated(m, "Oração"), s["small"], false, false)
+	setStyledCell(f, sheet, row, "A", l.OpeningSong+": "+m.InitSong, s["content"], false, false)
+	setStyledCell(f, sheet, row, "C", l.Prayer+": "+getDesignated(m, assigner.FUNC_ORACAO), s["small"], false, false)
+	writeTime(f, sheet, row, lay, timing, agenda.ItemOpeningSong, s)
 	row++
-	setStyledCell(f, sheet, row, "A", "Comentários iniciais (1 min)", s["content"], false, false)
+	setStyledCell(f, sheet, row, "A", l.OpeningComments, s["content"], false, false)
+	writeTime(f, sheet, row, lay, timing, agenda.ItemOpeningComments, s)
 	return row + 1
 }
 
-func writeSection(f *excelize.File, sheet string, row int, name string, items map[string]string, m parser.MeetingData, s map[string]int) int {
-	sectionStyle := map[string]int{
-		sectionTreasures:  s["gray"],
-		sectionMinistry:   s["orange"],
-		sectionChristians: s["wine"],
+func writeSection(f *excelize.File, sheet string, row int, section parser.Section, m parser.MeetingData, timing agenda.Agenda, lay layout, loc *locale.Locale, s map[string]int) int {
+	l := loc.Labels
+	sectionStyle := map[parser.Section]int{
+		parser.SectionTreasures: s["gray"],
+		parser.SectionMinistry:  s["orange"],
+		parser.SectionChristian: s["wine"],
 	}
 
-	setStyledCell(f, sheet, row, "A", name, sectionStyle[name], false, false)
+	setStyledCell(f, sheet, row, "A", loc.Headings[string(section)], sectionStyle[section], false, true)
 
-	if name == sectionMinistry {
-		setStyledCell(f, sheet, row, "B", "Sala B", s["small"], false, false)
-		setStyledCell(f, sheet, row, "C", "Salão principal", s["small"], false, false)
+	if section == parser.SectionMinistry && len(lay.halls) > 1 {
+		for _, hall := range lay.halls {
+			setStyledCell(f, sheet, row, hall.col, hall.name, s["small"], false, false)
+		}
 	}
 	row++
 
-	if name == sectionChristians {
-		setStyledCell(f, sheet, row, "A", "Cântico: "+m.MidSong, s["content"], false, false)
+	if section == parser.SectionChristian {
+		setStyledCell(f, sheet, row, "A", l.MidSong+": "+m.MidSong, s["content"], false, false)
+		writeTime(f, sheet, row, lay, timing, agenda.ItemMidSong, s)
 		row++
 	}
 
-	keys := getSortedKeys(items)
-	for _, k := range keys {
-		text := items[k]
-		setStyledCell(f, sheet, row, "A", text, s["content"], false, false)
-
-		lowerText := strings.ToLower(text)
+	for _, part := range m.PartsIn(section) {
+		k := part.Key()
+		setStyledCell(f, sheet, row, "A", part.Heading(), s["content"], false, false)
+		writePartMaterial(f, sheet, row, part, lay, loc, s)
+		if item, ok := timing.Part(part.Number); ok {
+			setStyledCell(f, sheet, row, lay.agenda, item.FormatRange(), s["small"], false, false)
+		}
 
-		if name == sectionMinistry {
-			valA := getDesignated(m, k+".A")
-			valB := getDesignated(m, k+".B")
-			if valA != "" {
-				setStyledCell(f, sheet, row, "C", valA, s["small"], false, false)
-			}
-			if valB != "" {
-				setStyledCell(f, sheet, row, "B", valB, s["small"], false, false)
-			}
-		} else if name == sectionTreasures && strings.Contains(lowerText, "leitura da bíblia") {
-			// Caso especial: Leitura da Bíblia → dois leitores
-			valA := getDesignated(m, k+".A")
-			valB := getDesignated(m, k+".B")
-			if valA != "" {
-				setStyledCell(f, sheet, row, "C", valA, s["small"], false, false)
-			}
-			if valB != "" {
-				setStyledCell(f, sheet, row, "B", valB, s["small"], false, false)
+		if isClassroomPart(part) {
+			// Uma designação por sala, cada uma na coluna da sua sala.
+			for _, hall := range lay.halls {
+				if d := getDesignated(m, k+"."+hall.code); d != "" {
+					setStyledCell(f, sheet, row, hall.col, d, s["small"], false, false)
+				}
 			}
+		} else if part.Kind == parser.KindCongregationStudy && m.Type == parser.WeekCircuitOverseer {
+			setStyledCell(f, sheet, row, "A", l.ServiceTalk, s["content"], false, false)
+			setStyledCell(f, sheet, row, "C", l.CircuitOverseer, s["small"], false, false)
 		} else {
-			// Caso padrão
-			if strings.Contains(lowerText, "estudo bíblico de congregação") {
-				setStyledCell(f, sheet, row, "B", "Dirigente/Leitor", s["small"], false, false)
+			if part.Kind == parser.KindCongregationStudy {
+				setStyledCell(f, sheet, row, "B", l.ConductorReader, s["small"], false, false)
 			}
 			if d := getDesignated(m, k); d != "" {
 				setStyledCell(f, sheet, row, "C", d, s["small"], false, false)
@@ -170,11 +266,65 @@ func writeSection(f *excelize.File, sheet string, row int, name string, items ma
 	return row + 1
 }
 
-func writeFooter(f *excelize.File, sheet string, row int, m parser.MeetingData, s map[string]int) {
-	setStyledCell(f, sheet, row, "A", "Comentários finais (3 min)", s["content"], false, false)
+// isClassroomPart indica as partes designadas em cada sala: a leitura da Bíblia
+// e todas as partes do ministério.
+func isClassroomPart(part parser.Part) bool {
+	return part.Kind == parser.KindBibleReading || part.Section == parser.SectionMinistry
+}
+
+// writePartMaterial anota a célula da parte com a descrição da apostila e põe a
+// lição de conselho na coluna de ponto de estudo.
+func writePartMaterial(f *excelize.File, sheet string, row int, part parser.Part, lay layout, loc *locale.Locale, s map[string]int) {
+	if part.Description != "" {
+		_ = f.AddComment(sheet, excelize.Comment{
+			Cell:   fmt.Sprintf("A%d", row),
+			Author: loc.Labels.Workbook,
+			Text:   part.Description,
+			Width:  320,
+			Height: 120,
+		})
+	}
+	if part.StudyReference != "" {
+		setStyledCell(f, sheet, row, lay.study, part.StudyReference, s["small"], false, false)
+	}
+}
+
+func writeFooter(f *excelize.File, sheet string, row int, m parser.MeetingData, timing agenda.Agenda, lay layout, loc *locale.Locale, s map[string]int) {
+	l := loc.Labels
+	setStyledCell(f, sheet, row, "A", l.ConcludingComments, s["content"], false, false)
+	writeTime(f, sheet, row, lay, timing, agenda.ItemConcludingComments, s)
 	row++
-	setStyledCell(f, sheet, row, "A", "Cântico Final: "+m.FinalSong, s["content"], false, false)
-	setStyledCell(f, sheet, row, "C", "Oração: "+getDesignated(m, "OraçãoFinal"), s["small"], false, false)
+	setStyledCell(f, sheet, row, "A", l.ClosingSong+": "+m.FinalSong, s["content"], false, false)
+	setStyledCell(f, sheet, row, "C", l.Prayer+": "+getDesignated(m, assigner.FUNC_ORACAO_FINAL), s["small"], false, false)
+	writeTime(f, sheet, row, lay, timing, agenda.ItemClosingSong, s)
+}
+
+// writeNoMeeting registra as semanas sem reunião de meio de semana.
+func writeNoMeeting(f *excelize.File, sheet string, m parser.MeetingData, opts Options, loc *locale.Locale, s map[string]int) {
+	notices := map[parser.WeekType]string{
+		parser.WeekAssembly: loc.Labels.NoMeetingAssembly,
+		parser.WeekMemorial: loc.Labels.NoMeetingMemorial,
+	}
+
+	row := 1
+	writeCongregation(f, sheet, row, opts, s)
+	row += 2
+	setStyledCell(f, sheet, row, "A", loc.Labels.Week+": "+m.MeetingDate, s["bold"], false, false)
+	row += 2
+	setStyledCell(f, sheet, row, "A", notices[m.Type], s["content"], false, false)
+}
+
+// writeCongregation põe o nome da congregação no topo da semana, quando houver.
+func writeCongregation(f *excelize.File, sheet string, row int, opts Options, s map[string]int) {
+	if opts.Congregation != "" {
+		setStyledCell(f, sheet, row, "A", opts.Congregation, s["bold"], true, true)
+	}
+}
+
+func writeTime(f *excelize.File, sheet string, row int, lay layout, timing agenda.Agenda, kind agenda.ItemKind, s map[string]int) {
+	if item, ok := timing.Find(kind); ok {
+		setStyledCell(f, sheet, row, lay.agenda, item.FormatRange(), s["small"], false, false)
+	}
 }
 
 func setStyledCell(f *excelize.File, sheet string, row int, col string, value string, style int, center bool, upper bool) {
@@ -210,54 +360,33 @@ func getDesignated(m parser.MeetingData, key string) string {
 	return ""
 }
 
-func getSortedKeys(m map[string]string) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	return keys
-}
-
-func GenerateDesignationsDoc(meetings []parser.MeetingData, period string) ([]byte, error) {
-	var builder strings.Builder
+func GenerateDesignationsDoc(meetings []parser.MeetingData, period string, slipsPerPage int, opts Options) ([]byte, error) {
+	loc := opts.locale()
+	lay := opts.layout(loc)
+	var slips []slip
 
 	for _, meeting := range meetings {
 		date := meeting.MeetingDate
-
-		keysTreasures := getSortedKeysDoc(meeting.TreasuresFromGodsWord)
-		for _, key := range keysTreasures {
-			partText := strings.ToLower(meeting.TreasuresFromGodsWord[key])
-
-			if strings.Contains(partText, "leitura da bíblia") {
-				designationA := meeting.Designated[key+".A"]
-				if designationA != "" {
-					builder.WriteString(buildDesignationBlock(designationA, date, key, "Salão principal"))
-				}
-				designationB := meeting.Designated[key+".B"]
-				if designationB != "" {
-					builder.WriteString(buildDesignationBlock(designationB, date, key, "Sala B"))
-				}
-			}
+		if !meeting.Date.IsZero() {
+			date = meeting.Date.Format(loc.DateLayout)
 		}
 
-		keysMinistry := getSortedKeys(meeting.ApplyYourselfToTheFieldMinistry)
-		for _, key := range keysMinistry {
-			designationA := meeting.Designated[key+".A"]
-			if designationA != "" {
-				builder.WriteString(buildDesignationBlock(designationA, date, key, "Salão principal"))
+		for _, part := range meeting.Parts {
+			if !isClassroomPart(part) {
+				continue
 			}
-			designationB := meeting.Designated[key+".B"]
-			if designationB != "" {
-				builder.WriteString(buildDesignationBlock(designationB, date, key, "Sala B"))
+			for _, hall := range lay.halls {
+				if designation := meeting.Designated[part.Key()+"."+hall.code]; designation != "" {
+					slips = append(slips, buildDesignationBlock(designation, date, part, hall.name))
+				}
 			}
 		}
 	}
 
-	return []byte(builder.String()), nil
+	return buildDocx(slips, slipsPerPage, "S-89 "+period, loc, opts.halls(loc))
 }
 
-func buildDesignationBlock(designation, date string, partNumber string, location string) string {
+func buildDesignationBlock(designation, date string, part parser.Part, location string) slip {
 	studentName := designation
 	helperName := ""
 	if strings.Contains(designation, "/") {
@@ -266,33 +395,14 @@ func buildDesignationBlock(designation, date string, partNumber string, location
 		helperName = strings.TrimSpace(parts[1])
 	}
 
-	var sb strings.Builder
-	sb.WriteString("DESIGNAÇÃO PARA A REUNIÃO\n")
-	sb.WriteString("NOSSA VIDA E MINISTÉRIO CRISTÃO\n\n")
-	sb.WriteString(fmt.Sprintf("Nome: %s\n", studentName))
-	if helperName != "" {
-		sb.WriteString(fmt.Sprintf("Ajudante: %s\n", helperName))
-	}
-	sb.WriteString(fmt.Sprintf("Data: %s\n", date))
-	sb.WriteString(fmt.Sprintf("Número da parte: %s\n", partNumber))
-	sb.WriteString(fmt.Sprintf("Local: %s\n", location))
-	sb.WriteString("\n")
-	sb.WriteString("Observação para o estudante: A lição e a fonte\n")
-	sb.WriteString("de matéria para a sua designação estão na Apostila\n")
-	sb.WriteString("da Reunião Vida e Ministério. Veja as instruções para a\n")
-	sb.WriteString("parte que estão nas Instruções para a Reunião Nossa\n")
-	sb.WriteString("Vida e Ministério Cristão (S-38).\n")
-	sb.WriteString("S-89-T 11/23\n")
-	sb.WriteString("\n\n")
-
-	return sb.String()
-}
+	return slip{
+		Student:   studentName,
+		Assistant: helperName,
+		Date:      date,
+		Part:      part.Key(),
+		Location:  location,
 
-func getSortedKeysDoc(m map[string]string) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+		StudyPoint: part.StudyReference,
+		Material:   part.Description,
 	}
-	sort.Strings(keys)
-	return keys
 }
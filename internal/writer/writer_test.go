@@ -0,0 +1,51 @@
+package writer
+
+import (
+	"bytes"
+	"midweek-project/internal/assigner"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// TestWriteToBufferHeader confere que presidente, conselheiros e orações saem
+// das chaves do assigner para o cabeçalho e o rodapé da semana.
+func TestWriteToBufferHeader(t *testing.T) {
+	meetings := testMeetings()
+	for key, name := range map[string]string{
+		assigner.FUNC_PRESIDENTE:    "João Silva",
+		assigner.FUNC_CONSELHEIRO:   "Pedro Souza",
+		assigner.FUNC_CONSELHEIRO_C: "Paulo Reis",
+		assigner.FUNC_ORACAO:        "Marcos Lima",
+		assigner.FUNC_ORACAO_FINAL:  "Rui Alves",
+	} {
+		meetings[0].Designated[key] = name
+	}
+
+	opts := DefaultOptions()
+	opts.Classrooms = 3
+	var buf bytes.Buffer
+	if err := WriteToBuffer(meetings, &buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := f.GetRows(meetings[0].MeetingDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cells []string
+	for _, row := range rows {
+		cells = append(cells, row...)
+	}
+	text := strings.Join(cells, "\n")
+
+	for _, name := range []string{"João Silva", "Pedro Souza", "Paulo Reis", "Marcos Lima", "Rui Alves"} {
+		if !strings.Contains(text, name) {
+			t.Errorf("sheet has no %q", name)
+		}
+	}
+}